@@ -27,6 +27,20 @@ type BookServiceClient interface {
 	CreateBook(ctx context.Context, in *CreateBookRequest, opts ...grpc.CallOption) (*CreateBookResponse, error)
 	UpdateBook(ctx context.Context, in *UpdateBookRequest, opts ...grpc.CallOption) (*UpdateBookResponse, error)
 	DeleteBook(ctx context.Context, in *DeleteBookRequest, opts ...grpc.CallOption) (*DeleteBookResponse, error)
+	SearchBooks(ctx context.Context, in *SearchBooksRequest, opts ...grpc.CallOption) (*SearchBooksResponse, error)
+	ListCopies(ctx context.Context, in *ListCopiesRequest, opts ...grpc.CallOption) (*ListCopiesResponse, error)
+	AddCopy(ctx context.Context, in *AddCopyRequest, opts ...grpc.CallOption) (*AddCopyResponse, error)
+	RetireCopy(ctx context.Context, in *RetireCopyRequest, opts ...grpc.CallOption) (*RetireCopyResponse, error)
+	RelocateCopy(ctx context.Context, in *RelocateCopyRequest, opts ...grpc.CallOption) (*RelocateCopyResponse, error)
+	ListDeletedBooks(ctx context.Context, in *ListDeletedBooksRequest, opts ...grpc.CallOption) (*ListDeletedBooksResponse, error)
+	RestoreBook(ctx context.Context, in *RestoreBookRequest, opts ...grpc.CallOption) (*RestoreBookResponse, error)
+	CountBooksByCategory(ctx context.Context, in *CountBooksByCategoryRequest, opts ...grpc.CallOption) (*CountBooksByCategoryResponse, error)
+	ReassignCategory(ctx context.Context, in *ReassignCategoryRequest, opts ...grpc.CallOption) (*ReassignCategoryResponse, error)
+	DeleteBooksByCategory(ctx context.Context, in *DeleteBooksByCategoryRequest, opts ...grpc.CallOption) (*DeleteBooksByCategoryResponse, error)
+	CloseCategory(ctx context.Context, in *CloseCategoryRequest, opts ...grpc.CallOption) (*CloseCategoryResponse, error)
+	ReopenCategory(ctx context.Context, in *ReopenCategoryRequest, opts ...grpc.CallOption) (*ReopenCategoryResponse, error)
+	ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error)
+	WatchBooks(ctx context.Context, in *WatchBooksRequest, opts ...grpc.CallOption) (BookService_WatchBooksClient, error)
 }
 
 type bookServiceClient struct {
@@ -82,6 +96,155 @@ func (c *bookServiceClient) DeleteBook(ctx context.Context, in *DeleteBookReques
 	return out, nil
 }
 
+func (c *bookServiceClient) SearchBooks(ctx context.Context, in *SearchBooksRequest, opts ...grpc.CallOption) (*SearchBooksResponse, error) {
+	out := new(SearchBooksResponse)
+	err := c.cc.Invoke(ctx, "/BookService/SearchBooks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) ListCopies(ctx context.Context, in *ListCopiesRequest, opts ...grpc.CallOption) (*ListCopiesResponse, error) {
+	out := new(ListCopiesResponse)
+	err := c.cc.Invoke(ctx, "/BookService/ListCopies", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) AddCopy(ctx context.Context, in *AddCopyRequest, opts ...grpc.CallOption) (*AddCopyResponse, error) {
+	out := new(AddCopyResponse)
+	err := c.cc.Invoke(ctx, "/BookService/AddCopy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) RetireCopy(ctx context.Context, in *RetireCopyRequest, opts ...grpc.CallOption) (*RetireCopyResponse, error) {
+	out := new(RetireCopyResponse)
+	err := c.cc.Invoke(ctx, "/BookService/RetireCopy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) RelocateCopy(ctx context.Context, in *RelocateCopyRequest, opts ...grpc.CallOption) (*RelocateCopyResponse, error) {
+	out := new(RelocateCopyResponse)
+	err := c.cc.Invoke(ctx, "/BookService/RelocateCopy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) ListDeletedBooks(ctx context.Context, in *ListDeletedBooksRequest, opts ...grpc.CallOption) (*ListDeletedBooksResponse, error) {
+	out := new(ListDeletedBooksResponse)
+	err := c.cc.Invoke(ctx, "/BookService/ListDeletedBooks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) RestoreBook(ctx context.Context, in *RestoreBookRequest, opts ...grpc.CallOption) (*RestoreBookResponse, error) {
+	out := new(RestoreBookResponse)
+	err := c.cc.Invoke(ctx, "/BookService/RestoreBook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) CountBooksByCategory(ctx context.Context, in *CountBooksByCategoryRequest, opts ...grpc.CallOption) (*CountBooksByCategoryResponse, error) {
+	out := new(CountBooksByCategoryResponse)
+	err := c.cc.Invoke(ctx, "/BookService/CountBooksByCategory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) ReassignCategory(ctx context.Context, in *ReassignCategoryRequest, opts ...grpc.CallOption) (*ReassignCategoryResponse, error) {
+	out := new(ReassignCategoryResponse)
+	err := c.cc.Invoke(ctx, "/BookService/ReassignCategory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) DeleteBooksByCategory(ctx context.Context, in *DeleteBooksByCategoryRequest, opts ...grpc.CallOption) (*DeleteBooksByCategoryResponse, error) {
+	out := new(DeleteBooksByCategoryResponse)
+	err := c.cc.Invoke(ctx, "/BookService/DeleteBooksByCategory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) CloseCategory(ctx context.Context, in *CloseCategoryRequest, opts ...grpc.CallOption) (*CloseCategoryResponse, error) {
+	out := new(CloseCategoryResponse)
+	err := c.cc.Invoke(ctx, "/BookService/CloseCategory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) ReopenCategory(ctx context.Context, in *ReopenCategoryRequest, opts ...grpc.CallOption) (*ReopenCategoryResponse, error) {
+	out := new(ReopenCategoryResponse)
+	err := c.cc.Invoke(ctx, "/BookService/ReopenCategory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error) {
+	out := new(ListAuditEntriesResponse)
+	err := c.cc.Invoke(ctx, "/BookService/ListAuditEntries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) WatchBooks(ctx context.Context, in *WatchBooksRequest, opts ...grpc.CallOption) (BookService_WatchBooksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BookService_ServiceDesc.Streams[0], "/BookService/WatchBooks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookServiceWatchBooksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BookService_WatchBooksClient interface {
+	Recv() (*BookEvent, error)
+	grpc.ClientStream
+}
+
+type bookServiceWatchBooksClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookServiceWatchBooksClient) Recv() (*BookEvent, error) {
+	m := new(BookEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BookServiceServer is the server API for BookService service.
 // All implementations must embed UnimplementedBookServiceServer
 // for forward compatibility
@@ -91,157 +254,1008 @@ type BookServiceServer interface {
 	CreateBook(context.Context, *CreateBookRequest) (*CreateBookResponse, error)
 	UpdateBook(context.Context, *UpdateBookRequest) (*UpdateBookResponse, error)
 	DeleteBook(context.Context, *DeleteBookRequest) (*DeleteBookResponse, error)
+	SearchBooks(context.Context, *SearchBooksRequest) (*SearchBooksResponse, error)
+	ListCopies(context.Context, *ListCopiesRequest) (*ListCopiesResponse, error)
+	AddCopy(context.Context, *AddCopyRequest) (*AddCopyResponse, error)
+	RetireCopy(context.Context, *RetireCopyRequest) (*RetireCopyResponse, error)
+	RelocateCopy(context.Context, *RelocateCopyRequest) (*RelocateCopyResponse, error)
+	ListDeletedBooks(context.Context, *ListDeletedBooksRequest) (*ListDeletedBooksResponse, error)
+	RestoreBook(context.Context, *RestoreBookRequest) (*RestoreBookResponse, error)
+	CountBooksByCategory(context.Context, *CountBooksByCategoryRequest) (*CountBooksByCategoryResponse, error)
+	ReassignCategory(context.Context, *ReassignCategoryRequest) (*ReassignCategoryResponse, error)
+	DeleteBooksByCategory(context.Context, *DeleteBooksByCategoryRequest) (*DeleteBooksByCategoryResponse, error)
+	CloseCategory(context.Context, *CloseCategoryRequest) (*CloseCategoryResponse, error)
+	ReopenCategory(context.Context, *ReopenCategoryRequest) (*ReopenCategoryResponse, error)
+	ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error)
+	WatchBooks(*WatchBooksRequest, BookService_WatchBooksServer) error
+	mustEmbedUnimplementedBookServiceServer()
+}
+
+// UnimplementedBookServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBookServiceServer struct {
+}
+
+func (UnimplementedBookServiceServer) GetBook(context.Context, *GetBookRequest) (*GetBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBook not implemented")
+}
+func (UnimplementedBookServiceServer) ListBooks(context.Context, *ListBooksRequest) (*ListBooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBooks not implemented")
+}
+func (UnimplementedBookServiceServer) CreateBook(context.Context, *CreateBookRequest) (*CreateBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateBook not implemented")
+}
+func (UnimplementedBookServiceServer) UpdateBook(context.Context, *UpdateBookRequest) (*UpdateBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateBook not implemented")
+}
+func (UnimplementedBookServiceServer) DeleteBook(context.Context, *DeleteBookRequest) (*DeleteBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBook not implemented")
+}
+func (UnimplementedBookServiceServer) SearchBooks(context.Context, *SearchBooksRequest) (*SearchBooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchBooks not implemented")
+}
+func (UnimplementedBookServiceServer) ListCopies(context.Context, *ListCopiesRequest) (*ListCopiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCopies not implemented")
+}
+func (UnimplementedBookServiceServer) AddCopy(context.Context, *AddCopyRequest) (*AddCopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCopy not implemented")
+}
+func (UnimplementedBookServiceServer) RetireCopy(context.Context, *RetireCopyRequest) (*RetireCopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetireCopy not implemented")
+}
+func (UnimplementedBookServiceServer) RelocateCopy(context.Context, *RelocateCopyRequest) (*RelocateCopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RelocateCopy not implemented")
+}
+func (UnimplementedBookServiceServer) ListDeletedBooks(context.Context, *ListDeletedBooksRequest) (*ListDeletedBooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeletedBooks not implemented")
+}
+func (UnimplementedBookServiceServer) RestoreBook(context.Context, *RestoreBookRequest) (*RestoreBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreBook not implemented")
+}
+func (UnimplementedBookServiceServer) CountBooksByCategory(context.Context, *CountBooksByCategoryRequest) (*CountBooksByCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountBooksByCategory not implemented")
+}
+func (UnimplementedBookServiceServer) ReassignCategory(context.Context, *ReassignCategoryRequest) (*ReassignCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReassignCategory not implemented")
+}
+func (UnimplementedBookServiceServer) DeleteBooksByCategory(context.Context, *DeleteBooksByCategoryRequest) (*DeleteBooksByCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBooksByCategory not implemented")
+}
+func (UnimplementedBookServiceServer) CloseCategory(context.Context, *CloseCategoryRequest) (*CloseCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseCategory not implemented")
+}
+func (UnimplementedBookServiceServer) ReopenCategory(context.Context, *ReopenCategoryRequest) (*ReopenCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReopenCategory not implemented")
+}
+func (UnimplementedBookServiceServer) ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEntries not implemented")
+}
+func (UnimplementedBookServiceServer) WatchBooks(*WatchBooksRequest, BookService_WatchBooksServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBooks not implemented")
+}
+func (UnimplementedBookServiceServer) mustEmbedUnimplementedBookServiceServer() {}
+
+// UnsafeBookServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BookServiceServer will
+// result in compilation errors.
+type UnsafeBookServiceServer interface {
 	mustEmbedUnimplementedBookServiceServer()
 }
 
-// UnimplementedBookServiceServer must be embedded to have forward compatible implementations.
-type UnimplementedBookServiceServer struct {
+func RegisterBookServiceServer(s grpc.ServiceRegistrar, srv BookServiceServer) {
+	s.RegisterService(&BookService_ServiceDesc, srv)
+}
+
+func _BookService_GetBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).GetBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/GetBook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).GetBook(ctx, req.(*GetBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_ListBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).ListBooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/ListBooks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).ListBooks(ctx, req.(*ListBooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_CreateBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).CreateBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/CreateBook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).CreateBook(ctx, req.(*CreateBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_UpdateBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).UpdateBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/UpdateBook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).UpdateBook(ctx, req.(*UpdateBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_DeleteBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).DeleteBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/DeleteBook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).DeleteBook(ctx, req.(*DeleteBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_SearchBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchBooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).SearchBooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/SearchBooks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).SearchBooks(ctx, req.(*SearchBooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_ListCopies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCopiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).ListCopies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/ListCopies",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).ListCopies(ctx, req.(*ListCopiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_AddCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).AddCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/AddCopy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).AddCopy(ctx, req.(*AddCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_RetireCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetireCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).RetireCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/RetireCopy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).RetireCopy(ctx, req.(*RetireCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_RelocateCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RelocateCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).RelocateCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/RelocateCopy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).RelocateCopy(ctx, req.(*RelocateCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_ListDeletedBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeletedBooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).ListDeletedBooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/ListDeletedBooks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).ListDeletedBooks(ctx, req.(*ListDeletedBooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_RestoreBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).RestoreBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/RestoreBook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).RestoreBook(ctx, req.(*RestoreBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_CountBooksByCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountBooksByCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).CountBooksByCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/CountBooksByCategory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).CountBooksByCategory(ctx, req.(*CountBooksByCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_ReassignCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReassignCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).ReassignCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/ReassignCategory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).ReassignCategory(ctx, req.(*ReassignCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_DeleteBooksByCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBooksByCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).DeleteBooksByCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/DeleteBooksByCategory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).DeleteBooksByCategory(ctx, req.(*DeleteBooksByCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_CloseCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).CloseCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/CloseCategory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).CloseCategory(ctx, req.(*CloseCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_ReopenCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReopenCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).ReopenCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/ReopenCategory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).ReopenCategory(ctx, req.(*ReopenCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_ListAuditEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).ListAuditEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookService/ListAuditEntries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).ListAuditEntries(ctx, req.(*ListAuditEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_WatchBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchBooksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookServiceServer).WatchBooks(m, &bookServiceWatchBooksServer{stream})
+}
+
+type BookService_WatchBooksServer interface {
+	Send(*BookEvent) error
+	grpc.ServerStream
+}
+
+type bookServiceWatchBooksServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookServiceWatchBooksServer) Send(m *BookEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// BookService_ServiceDesc is the grpc.ServiceDesc for BookService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BookService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "BookService",
+	HandlerType: (*BookServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBook",
+			Handler:    _BookService_GetBook_Handler,
+		},
+		{
+			MethodName: "ListBooks",
+			Handler:    _BookService_ListBooks_Handler,
+		},
+		{
+			MethodName: "CreateBook",
+			Handler:    _BookService_CreateBook_Handler,
+		},
+		{
+			MethodName: "UpdateBook",
+			Handler:    _BookService_UpdateBook_Handler,
+		},
+		{
+			MethodName: "DeleteBook",
+			Handler:    _BookService_DeleteBook_Handler,
+		},
+		{
+			MethodName: "SearchBooks",
+			Handler:    _BookService_SearchBooks_Handler,
+		},
+		{
+			MethodName: "ListCopies",
+			Handler:    _BookService_ListCopies_Handler,
+		},
+		{
+			MethodName: "AddCopy",
+			Handler:    _BookService_AddCopy_Handler,
+		},
+		{
+			MethodName: "RetireCopy",
+			Handler:    _BookService_RetireCopy_Handler,
+		},
+		{
+			MethodName: "RelocateCopy",
+			Handler:    _BookService_RelocateCopy_Handler,
+		},
+		{
+			MethodName: "ListDeletedBooks",
+			Handler:    _BookService_ListDeletedBooks_Handler,
+		},
+		{
+			MethodName: "RestoreBook",
+			Handler:    _BookService_RestoreBook_Handler,
+		},
+		{
+			MethodName: "CountBooksByCategory",
+			Handler:    _BookService_CountBooksByCategory_Handler,
+		},
+		{
+			MethodName: "ReassignCategory",
+			Handler:    _BookService_ReassignCategory_Handler,
+		},
+		{
+			MethodName: "DeleteBooksByCategory",
+			Handler:    _BookService_DeleteBooksByCategory_Handler,
+		},
+		{
+			MethodName: "CloseCategory",
+			Handler:    _BookService_CloseCategory_Handler,
+		},
+		{
+			MethodName: "ReopenCategory",
+			Handler:    _BookService_ReopenCategory_Handler,
+		},
+		{
+			MethodName: "ListAuditEntries",
+			Handler:    _BookService_ListAuditEntries_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchBooks",
+			Handler:       _BookService_WatchBooks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/api.proto",
+}
+
+// LoanServiceClient is the client API for LoanService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LoanServiceClient interface {
+	CheckoutCopy(ctx context.Context, in *CheckoutCopyRequest, opts ...grpc.CallOption) (*CheckoutCopyResponse, error)
+	ReturnCopy(ctx context.Context, in *ReturnCopyRequest, opts ...grpc.CallOption) (*ReturnCopyResponse, error)
+	RenewLoan(ctx context.Context, in *RenewLoanRequest, opts ...grpc.CallOption) (*RenewLoanResponse, error)
+	ListLoans(ctx context.Context, in *ListLoansRequest, opts ...grpc.CallOption) (*ListLoansResponse, error)
+	PlaceHold(ctx context.Context, in *PlaceHoldRequest, opts ...grpc.CallOption) (*PlaceHoldResponse, error)
+	CancelHold(ctx context.Context, in *CancelHoldRequest, opts ...grpc.CallOption) (*CancelHoldResponse, error)
+	GetHold(ctx context.Context, in *GetHoldRequest, opts ...grpc.CallOption) (*GetHoldResponse, error)
+	ListHolds(ctx context.Context, in *ListHoldsRequest, opts ...grpc.CallOption) (*ListHoldsResponse, error)
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	ListLedger(ctx context.Context, in *ListLedgerRequest, opts ...grpc.CallOption) (*ListLedgerResponse, error)
+	WaiveFine(ctx context.Context, in *WaiveFineRequest, opts ...grpc.CallOption) (*WaiveFineResponse, error)
+	RecordPayment(ctx context.Context, in *RecordPaymentRequest, opts ...grpc.CallOption) (*RecordPaymentResponse, error)
+}
+
+type loanServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLoanServiceClient(cc grpc.ClientConnInterface) LoanServiceClient {
+	return &loanServiceClient{cc}
+}
+
+func (c *loanServiceClient) CheckoutCopy(ctx context.Context, in *CheckoutCopyRequest, opts ...grpc.CallOption) (*CheckoutCopyResponse, error) {
+	out := new(CheckoutCopyResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/CheckoutCopy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) ReturnCopy(ctx context.Context, in *ReturnCopyRequest, opts ...grpc.CallOption) (*ReturnCopyResponse, error) {
+	out := new(ReturnCopyResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/ReturnCopy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) RenewLoan(ctx context.Context, in *RenewLoanRequest, opts ...grpc.CallOption) (*RenewLoanResponse, error) {
+	out := new(RenewLoanResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/RenewLoan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) ListLoans(ctx context.Context, in *ListLoansRequest, opts ...grpc.CallOption) (*ListLoansResponse, error) {
+	out := new(ListLoansResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/ListLoans", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) PlaceHold(ctx context.Context, in *PlaceHoldRequest, opts ...grpc.CallOption) (*PlaceHoldResponse, error) {
+	out := new(PlaceHoldResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/PlaceHold", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) CancelHold(ctx context.Context, in *CancelHoldRequest, opts ...grpc.CallOption) (*CancelHoldResponse, error) {
+	out := new(CancelHoldResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/CancelHold", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) GetHold(ctx context.Context, in *GetHoldRequest, opts ...grpc.CallOption) (*GetHoldResponse, error) {
+	out := new(GetHoldResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/GetHold", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) ListHolds(ctx context.Context, in *ListHoldsRequest, opts ...grpc.CallOption) (*ListHoldsResponse, error) {
+	out := new(ListHoldsResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/ListHolds", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error) {
+	out := new(GetBalanceResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/GetBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) ListLedger(ctx context.Context, in *ListLedgerRequest, opts ...grpc.CallOption) (*ListLedgerResponse, error) {
+	out := new(ListLedgerResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/ListLedger", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) WaiveFine(ctx context.Context, in *WaiveFineRequest, opts ...grpc.CallOption) (*WaiveFineResponse, error) {
+	out := new(WaiveFineResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/WaiveFine", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) RecordPayment(ctx context.Context, in *RecordPaymentRequest, opts ...grpc.CallOption) (*RecordPaymentResponse, error) {
+	out := new(RecordPaymentResponse)
+	err := c.cc.Invoke(ctx, "/LoanService/RecordPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoanServiceServer is the server API for LoanService service.
+// All implementations must embed UnimplementedLoanServiceServer
+// for forward compatibility
+type LoanServiceServer interface {
+	CheckoutCopy(context.Context, *CheckoutCopyRequest) (*CheckoutCopyResponse, error)
+	ReturnCopy(context.Context, *ReturnCopyRequest) (*ReturnCopyResponse, error)
+	RenewLoan(context.Context, *RenewLoanRequest) (*RenewLoanResponse, error)
+	ListLoans(context.Context, *ListLoansRequest) (*ListLoansResponse, error)
+	PlaceHold(context.Context, *PlaceHoldRequest) (*PlaceHoldResponse, error)
+	CancelHold(context.Context, *CancelHoldRequest) (*CancelHoldResponse, error)
+	GetHold(context.Context, *GetHoldRequest) (*GetHoldResponse, error)
+	ListHolds(context.Context, *ListHoldsRequest) (*ListHoldsResponse, error)
+	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	ListLedger(context.Context, *ListLedgerRequest) (*ListLedgerResponse, error)
+	WaiveFine(context.Context, *WaiveFineRequest) (*WaiveFineResponse, error)
+	RecordPayment(context.Context, *RecordPaymentRequest) (*RecordPaymentResponse, error)
+	mustEmbedUnimplementedLoanServiceServer()
+}
+
+// UnimplementedLoanServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedLoanServiceServer struct {
+}
+
+func (UnimplementedLoanServiceServer) CheckoutCopy(context.Context, *CheckoutCopyRequest) (*CheckoutCopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckoutCopy not implemented")
+}
+func (UnimplementedLoanServiceServer) ReturnCopy(context.Context, *ReturnCopyRequest) (*ReturnCopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReturnCopy not implemented")
+}
+func (UnimplementedLoanServiceServer) RenewLoan(context.Context, *RenewLoanRequest) (*RenewLoanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewLoan not implemented")
+}
+func (UnimplementedLoanServiceServer) ListLoans(context.Context, *ListLoansRequest) (*ListLoansResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLoans not implemented")
+}
+func (UnimplementedLoanServiceServer) PlaceHold(context.Context, *PlaceHoldRequest) (*PlaceHoldResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceHold not implemented")
+}
+func (UnimplementedLoanServiceServer) CancelHold(context.Context, *CancelHoldRequest) (*CancelHoldResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelHold not implemented")
+}
+func (UnimplementedLoanServiceServer) GetHold(context.Context, *GetHoldRequest) (*GetHoldResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHold not implemented")
+}
+func (UnimplementedLoanServiceServer) ListHolds(context.Context, *ListHoldsRequest) (*ListHoldsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHolds not implemented")
+}
+func (UnimplementedLoanServiceServer) GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedLoanServiceServer) ListLedger(context.Context, *ListLedgerRequest) (*ListLedgerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLedger not implemented")
+}
+func (UnimplementedLoanServiceServer) WaiveFine(context.Context, *WaiveFineRequest) (*WaiveFineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaiveFine not implemented")
+}
+func (UnimplementedLoanServiceServer) RecordPayment(context.Context, *RecordPaymentRequest) (*RecordPaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordPayment not implemented")
+}
+func (UnimplementedLoanServiceServer) mustEmbedUnimplementedLoanServiceServer() {}
+
+// UnsafeLoanServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LoanServiceServer will
+// result in compilation errors.
+type UnsafeLoanServiceServer interface {
+	mustEmbedUnimplementedLoanServiceServer()
 }
 
-func (UnimplementedBookServiceServer) GetBook(context.Context, *GetBookRequest) (*GetBookResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetBook not implemented")
+func RegisterLoanServiceServer(s grpc.ServiceRegistrar, srv LoanServiceServer) {
+	s.RegisterService(&LoanService_ServiceDesc, srv)
 }
-func (UnimplementedBookServiceServer) ListBooks(context.Context, *ListBooksRequest) (*ListBooksResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ListBooks not implemented")
+
+func _LoanService_CheckoutCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckoutCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).CheckoutCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/LoanService/CheckoutCopy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).CheckoutCopy(ctx, req.(*CheckoutCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedBookServiceServer) CreateBook(context.Context, *CreateBookRequest) (*CreateBookResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CreateBook not implemented")
+
+func _LoanService_ReturnCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReturnCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).ReturnCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/LoanService/ReturnCopy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).ReturnCopy(ctx, req.(*ReturnCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedBookServiceServer) UpdateBook(context.Context, *UpdateBookRequest) (*UpdateBookResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method UpdateBook not implemented")
+
+func _LoanService_RenewLoan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewLoanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).RenewLoan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/LoanService/RenewLoan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).RenewLoan(ctx, req.(*RenewLoanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedBookServiceServer) DeleteBook(context.Context, *DeleteBookRequest) (*DeleteBookResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteBook not implemented")
+
+func _LoanService_ListLoans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLoansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).ListLoans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/LoanService/ListLoans",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).ListLoans(ctx, req.(*ListLoansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedBookServiceServer) mustEmbedUnimplementedBookServiceServer() {}
 
-// UnsafeBookServiceServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to BookServiceServer will
-// result in compilation errors.
-type UnsafeBookServiceServer interface {
-	mustEmbedUnimplementedBookServiceServer()
+func _LoanService_PlaceHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceHoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).PlaceHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/LoanService/PlaceHold",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).PlaceHold(ctx, req.(*PlaceHoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterBookServiceServer(s grpc.ServiceRegistrar, srv BookServiceServer) {
-	s.RegisterService(&BookService_ServiceDesc, srv)
+func _LoanService_CancelHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelHoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).CancelHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/LoanService/CancelHold",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).CancelHold(ctx, req.(*CancelHoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _BookService_GetBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetBookRequest)
+func _LoanService_GetHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHoldRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BookServiceServer).GetBook(ctx, in)
+		return srv.(LoanServiceServer).GetHold(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/BookService/GetBook",
+		FullMethod: "/LoanService/GetHold",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BookServiceServer).GetBook(ctx, req.(*GetBookRequest))
+		return srv.(LoanServiceServer).GetHold(ctx, req.(*GetHoldRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BookService_ListBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ListBooksRequest)
+func _LoanService_ListHolds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHoldsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BookServiceServer).ListBooks(ctx, in)
+		return srv.(LoanServiceServer).ListHolds(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/BookService/ListBooks",
+		FullMethod: "/LoanService/ListHolds",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BookServiceServer).ListBooks(ctx, req.(*ListBooksRequest))
+		return srv.(LoanServiceServer).ListHolds(ctx, req.(*ListHoldsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BookService_CreateBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CreateBookRequest)
+func _LoanService_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BookServiceServer).CreateBook(ctx, in)
+		return srv.(LoanServiceServer).GetBalance(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/BookService/CreateBook",
+		FullMethod: "/LoanService/GetBalance",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BookServiceServer).CreateBook(ctx, req.(*CreateBookRequest))
+		return srv.(LoanServiceServer).GetBalance(ctx, req.(*GetBalanceRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BookService_UpdateBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UpdateBookRequest)
+func _LoanService_ListLedger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLedgerRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BookServiceServer).UpdateBook(ctx, in)
+		return srv.(LoanServiceServer).ListLedger(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/BookService/UpdateBook",
+		FullMethod: "/LoanService/ListLedger",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BookServiceServer).UpdateBook(ctx, req.(*UpdateBookRequest))
+		return srv.(LoanServiceServer).ListLedger(ctx, req.(*ListLedgerRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BookService_DeleteBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteBookRequest)
+func _LoanService_WaiveFine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WaiveFineRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BookServiceServer).DeleteBook(ctx, in)
+		return srv.(LoanServiceServer).WaiveFine(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/BookService/DeleteBook",
+		FullMethod: "/LoanService/WaiveFine",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BookServiceServer).DeleteBook(ctx, req.(*DeleteBookRequest))
+		return srv.(LoanServiceServer).WaiveFine(ctx, req.(*WaiveFineRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// BookService_ServiceDesc is the grpc.ServiceDesc for BookService service.
+func _LoanService_RecordPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).RecordPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/LoanService/RecordPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).RecordPayment(ctx, req.(*RecordPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LoanService_ServiceDesc is the grpc.ServiceDesc for LoanService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
-var BookService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "BookService",
-	HandlerType: (*BookServiceServer)(nil),
+var LoanService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "LoanService",
+	HandlerType: (*LoanServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "GetBook",
-			Handler:    _BookService_GetBook_Handler,
+			MethodName: "CheckoutCopy",
+			Handler:    _LoanService_CheckoutCopy_Handler,
 		},
 		{
-			MethodName: "ListBooks",
-			Handler:    _BookService_ListBooks_Handler,
+			MethodName: "ReturnCopy",
+			Handler:    _LoanService_ReturnCopy_Handler,
 		},
 		{
-			MethodName: "CreateBook",
-			Handler:    _BookService_CreateBook_Handler,
+			MethodName: "RenewLoan",
+			Handler:    _LoanService_RenewLoan_Handler,
 		},
 		{
-			MethodName: "UpdateBook",
-			Handler:    _BookService_UpdateBook_Handler,
+			MethodName: "ListLoans",
+			Handler:    _LoanService_ListLoans_Handler,
 		},
 		{
-			MethodName: "DeleteBook",
-			Handler:    _BookService_DeleteBook_Handler,
+			MethodName: "PlaceHold",
+			Handler:    _LoanService_PlaceHold_Handler,
+		},
+		{
+			MethodName: "CancelHold",
+			Handler:    _LoanService_CancelHold_Handler,
+		},
+		{
+			MethodName: "GetHold",
+			Handler:    _LoanService_GetHold_Handler,
+		},
+		{
+			MethodName: "ListHolds",
+			Handler:    _LoanService_ListHolds_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _LoanService_GetBalance_Handler,
+		},
+		{
+			MethodName: "ListLedger",
+			Handler:    _LoanService_ListLedger_Handler,
+		},
+		{
+			MethodName: "WaiveFine",
+			Handler:    _LoanService_WaiveFine_Handler,
+		},
+		{
+			MethodName: "RecordPayment",
+			Handler:    _LoanService_RecordPayment_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -257,6 +1271,10 @@ type BookCategoryServiceClient interface {
 	CreateCategory(ctx context.Context, in *CreateCategoryRequest, opts ...grpc.CallOption) (*CreateCategoryResponse, error)
 	UpdateCategory(ctx context.Context, in *UpdateCategoryRequest, opts ...grpc.CallOption) (*UpdateCategoryResponse, error)
 	DeleteCategory(ctx context.Context, in *DeleteCategoryRequest, opts ...grpc.CallOption) (*DeleteCategoryResponse, error)
+	ListDeletedCategories(ctx context.Context, in *ListDeletedCategoriesRequest, opts ...grpc.CallOption) (*ListDeletedCategoriesResponse, error)
+	RestoreCategory(ctx context.Context, in *RestoreCategoryRequest, opts ...grpc.CallOption) (*RestoreCategoryResponse, error)
+	ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error)
+	WatchCategories(ctx context.Context, in *WatchCategoriesRequest, opts ...grpc.CallOption) (BookCategoryService_WatchCategoriesClient, error)
 }
 
 type bookCategoryServiceClient struct {
@@ -312,6 +1330,65 @@ func (c *bookCategoryServiceClient) DeleteCategory(ctx context.Context, in *Dele
 	return out, nil
 }
 
+func (c *bookCategoryServiceClient) ListDeletedCategories(ctx context.Context, in *ListDeletedCategoriesRequest, opts ...grpc.CallOption) (*ListDeletedCategoriesResponse, error) {
+	out := new(ListDeletedCategoriesResponse)
+	err := c.cc.Invoke(ctx, "/BookCategoryService/ListDeletedCategories", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCategoryServiceClient) RestoreCategory(ctx context.Context, in *RestoreCategoryRequest, opts ...grpc.CallOption) (*RestoreCategoryResponse, error) {
+	out := new(RestoreCategoryResponse)
+	err := c.cc.Invoke(ctx, "/BookCategoryService/RestoreCategory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCategoryServiceClient) ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error) {
+	out := new(ListAuditEntriesResponse)
+	err := c.cc.Invoke(ctx, "/BookCategoryService/ListAuditEntries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCategoryServiceClient) WatchCategories(ctx context.Context, in *WatchCategoriesRequest, opts ...grpc.CallOption) (BookCategoryService_WatchCategoriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BookCategoryService_ServiceDesc.Streams[0], "/BookCategoryService/WatchCategories", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookCategoryServiceWatchCategoriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BookCategoryService_WatchCategoriesClient interface {
+	Recv() (*CategoryEvent, error)
+	grpc.ClientStream
+}
+
+type bookCategoryServiceWatchCategoriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookCategoryServiceWatchCategoriesClient) Recv() (*CategoryEvent, error) {
+	m := new(CategoryEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BookCategoryServiceServer is the server API for BookCategoryService service.
 // All implementations must embed UnimplementedBookCategoryServiceServer
 // for forward compatibility
@@ -321,6 +1398,10 @@ type BookCategoryServiceServer interface {
 	CreateCategory(context.Context, *CreateCategoryRequest) (*CreateCategoryResponse, error)
 	UpdateCategory(context.Context, *UpdateCategoryRequest) (*UpdateCategoryResponse, error)
 	DeleteCategory(context.Context, *DeleteCategoryRequest) (*DeleteCategoryResponse, error)
+	ListDeletedCategories(context.Context, *ListDeletedCategoriesRequest) (*ListDeletedCategoriesResponse, error)
+	RestoreCategory(context.Context, *RestoreCategoryRequest) (*RestoreCategoryResponse, error)
+	ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error)
+	WatchCategories(*WatchCategoriesRequest, BookCategoryService_WatchCategoriesServer) error
 	mustEmbedUnimplementedBookCategoryServiceServer()
 }
 
@@ -343,6 +1424,18 @@ func (UnimplementedBookCategoryServiceServer) UpdateCategory(context.Context, *U
 func (UnimplementedBookCategoryServiceServer) DeleteCategory(context.Context, *DeleteCategoryRequest) (*DeleteCategoryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteCategory not implemented")
 }
+func (UnimplementedBookCategoryServiceServer) ListDeletedCategories(context.Context, *ListDeletedCategoriesRequest) (*ListDeletedCategoriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeletedCategories not implemented")
+}
+func (UnimplementedBookCategoryServiceServer) RestoreCategory(context.Context, *RestoreCategoryRequest) (*RestoreCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreCategory not implemented")
+}
+func (UnimplementedBookCategoryServiceServer) ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEntries not implemented")
+}
+func (UnimplementedBookCategoryServiceServer) WatchCategories(*WatchCategoriesRequest, BookCategoryService_WatchCategoriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCategories not implemented")
+}
 func (UnimplementedBookCategoryServiceServer) mustEmbedUnimplementedBookCategoryServiceServer() {}
 
 // UnsafeBookCategoryServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -446,6 +1539,81 @@ func _BookCategoryService_DeleteCategory_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BookCategoryService_ListDeletedCategories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeletedCategoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCategoryServiceServer).ListDeletedCategories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookCategoryService/ListDeletedCategories",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCategoryServiceServer).ListDeletedCategories(ctx, req.(*ListDeletedCategoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCategoryService_RestoreCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCategoryServiceServer).RestoreCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookCategoryService/RestoreCategory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCategoryServiceServer).RestoreCategory(ctx, req.(*RestoreCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCategoryService_ListAuditEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCategoryServiceServer).ListAuditEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookCategoryService/ListAuditEntries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCategoryServiceServer).ListAuditEntries(ctx, req.(*ListAuditEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCategoryService_WatchCategories_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCategoriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookCategoryServiceServer).WatchCategories(m, &bookCategoryServiceWatchCategoriesServer{stream})
+}
+
+type BookCategoryService_WatchCategoriesServer interface {
+	Send(*CategoryEvent) error
+	grpc.ServerStream
+}
+
+type bookCategoryServiceWatchCategoriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookCategoryServiceWatchCategoriesServer) Send(m *CategoryEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // BookCategoryService_ServiceDesc is the grpc.ServiceDesc for BookCategoryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -473,8 +1641,26 @@ var BookCategoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteCategory",
 			Handler:    _BookCategoryService_DeleteCategory_Handler,
 		},
+		{
+			MethodName: "ListDeletedCategories",
+			Handler:    _BookCategoryService_ListDeletedCategories_Handler,
+		},
+		{
+			MethodName: "RestoreCategory",
+			Handler:    _BookCategoryService_RestoreCategory_Handler,
+		},
+		{
+			MethodName: "ListAuditEntries",
+			Handler:    _BookCategoryService_ListAuditEntries_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCategories",
+			Handler:       _BookCategoryService_WatchCategories_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/api.proto",
 }
 
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-category-service/config"
+	"github.com/daffaromero/gobook/services/book-category-service/controller"
+	"github.com/daffaromero/gobook/services/book-category-service/migrations"
+	"github.com/daffaromero/gobook/services/book-category-service/repository"
+	"github.com/daffaromero/gobook/services/book-category-service/repository/query"
+	"github.com/daffaromero/gobook/services/book-category-service/service"
+	"github.com/daffaromero/gobook/services/common/audit"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/discovery"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/outbox"
+	"github.com/daffaromero/gobook/services/common/servicetest"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	consul "github.com/hashicorp/consul/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// stubBooks is a book-service holding count books in every category. While
+// err is set, CloseCategory fails with it; otherwise it calls onClose, if
+// set, before answering.
+type stubBooks struct {
+	api.UnimplementedBookServiceServer
+
+	mu       sync.Mutex
+	count    int64
+	err      error
+	onClose  func()
+	reopened []string
+}
+
+func (b *stubBooks) set(count int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.count, b.err = count, err
+}
+
+func (b *stubBooks) CloseCategory(ctx context.Context, req *api.CloseCategoryRequest) (*api.CloseCategoryResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.count > 0 && req.Mode == api.DeleteCategoryMode_DELETE_CATEGORY_MODE_REFUSE {
+		return nil, status.Errorf(codes.Aborted, "category with ID %s still has %d books", req.CategoryId, b.count)
+	}
+	if b.onClose != nil {
+		b.onClose()
+	}
+	affected := b.count
+	b.count = 0
+	return &api.CloseCategoryResponse{AffectedBooks: affected}, nil
+}
+
+func (b *stubBooks) ReopenCategory(ctx context.Context, req *api.ReopenCategoryRequest) (*api.ReopenCategoryResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reopened = append(b.reopened, req.CategoryId)
+	return &api.ReopenCategoryResponse{}, nil
+}
+
+// staticRegistry resolves every service to one address.
+type staticRegistry struct {
+	discovery.Registry
+
+	host string
+	port int
+}
+
+func (r *staticRegistry) WatchService(ctx context.Context, serviceName string, index uint64) ([]*consul.ServiceEntry, uint64, error) {
+	if index > 0 {
+		<-ctx.Done()
+		return nil, index, ctx.Err()
+	}
+	entry := &consul.ServiceEntry{
+		Node:    &consul.Node{Address: r.host},
+		Service: &consul.AgentService{Service: serviceName, Address: r.host, Port: r.port},
+	}
+	return []*consul.ServiceEntry{entry}, 1, nil
+}
+
+// startBookService serves books on a local port and returns a registry that
+// resolves to it.
+func startBookService(t *testing.T, books *stubBooks) discovery.Registry {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	api.RegisterBookServiceServer(server, books)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portInt, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &staticRegistry{host: host, port: portInt}
+}
+
+type categoryFixture struct {
+	service service.CategoryService
+	books   *stubBooks
+	writers []servicetest.Writer
+}
+
+// newCategoryFixture serves categories from the PostgreSQL database named by
+// TEST_BOOK_CATS_DATABASE_URL over HTTP and gRPC. Tests using it are skipped
+// without one.
+func newCategoryFixture(t *testing.T) *categoryFixture {
+	t.Helper()
+
+	pool := servicetest.OpenDB(t, "TEST_BOOK_CATS_DATABASE_URL", migrations.FS)
+	if config.TimeOutDuration <= 0 {
+		config.TimeOutDuration = 10
+	}
+
+	repo := repository.NewCategoryRepository(repository.NewStore(pool), query.NewCategoryQuery(pool), audit.NewQuery(pool), outbox.NewFeed(pool, logs))
+	books := &stubBooks{}
+	categoryService := service.NewCategoryService(startBookService(t, books), repo, logs)
+
+	tokens, token := servicetest.Token(t, auth.RoleLibrarian)
+	policy := auth.NewPolicy(config.AccessRules)
+
+	app := fiber.New()
+	controller.NewCategoryController(validator.New(), categoryService, auth.Middleware(tokens), policy).Route(app)
+
+	server := newGRPCServer(tokens, policy)
+	NewCategoryGRPCHandler(server, categoryService)
+	client := api.NewBookCategoryServiceClient(servicetest.Dial(t, server))
+	ctx := servicetest.WithToken(token)
+
+	return &categoryFixture{
+		service: categoryService,
+		books:   books,
+		writers: []servicetest.Writer{
+			servicetest.HTTPWriter(app, config.EndpointPrefix, token, func() string {
+				return `{"category":{"name":"Renamed ` + uuid.New().String() + `"}}`
+			}),
+			{
+				Name: "gRPC",
+				Update: func(id string, version int64) string {
+					_, err := client.UpdateCategory(ctx, &api.UpdateCategoryRequest{
+						Category:        &api.BookCategory{Id: id, Name: "Renamed " + uuid.New().String()},
+						UpdateMask:      &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+						ExpectedVersion: version,
+					})
+					return servicetest.GRPCOutcome(err)
+				},
+				Delete: func(id string, version int64) string {
+					_, err := client.DeleteCategory(ctx, &api.DeleteCategoryRequest{CategoryId: id, ExpectedVersion: version})
+					return servicetest.GRPCOutcome(err)
+				},
+			},
+		},
+	}
+}
+
+func (f *categoryFixture) createCategory(t *testing.T) *api.BookCategory {
+	t.Helper()
+
+	res, err := f.service.CreateCategory(context.Background(), &api.CreateCategoryRequest{Category: &api.BookCategory{}}, "Category "+uuid.New().String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Category
+}
+
+func TestCategoryWrites(t *testing.T) {
+	f := newCategoryFixture(t)
+
+	servicetest.TestWrites(t, f.writers, func(t *testing.T) (string, int64) {
+		category := f.createCategory(t)
+		return category.Id, category.Version
+	})
+}
+
+// A category whose books cannot be dealt with is left as it was.
+func TestDeleteCategoryKeepsCategoryWhenClosingFails(t *testing.T) {
+	f := newCategoryFixture(t)
+	ctx := context.Background()
+	category := f.createCategory(t)
+
+	f.books.set(3, status.Error(codes.Internal, "cascade failed"))
+	_, err := f.service.DeleteCategory(ctx, &api.DeleteCategoryRequest{
+		CategoryId:      category.Id,
+		ExpectedVersion: category.Version,
+		Mode:            api.DeleteCategoryMode_DELETE_CATEGORY_MODE_CASCADE,
+	})
+	if err == nil {
+		t.Fatal("DeleteCategory succeeded while deleting its books failed")
+	}
+
+	current, err := f.service.GetCategory(ctx, &api.GetCategoryRequest{CategoryId: category.Id})
+	if err != nil {
+		t.Fatalf("category was deleted although its books were not: %v", err)
+	}
+	if current.Category.Version != category.Version {
+		t.Errorf("version = %d after the failed delete, want it unchanged at %d", current.Category.Version, category.Version)
+	}
+
+	f.books.set(3, nil)
+	res, err := f.service.DeleteCategory(ctx, &api.DeleteCategoryRequest{
+		CategoryId:      category.Id,
+		ExpectedVersion: category.Version,
+		Mode:            api.DeleteCategoryMode_DELETE_CATEGORY_MODE_CASCADE,
+	})
+	if err != nil {
+		t.Fatalf("DeleteCategory after the failure: %v", err)
+	}
+	if res.AffectedBooks != 3 {
+		t.Errorf("AffectedBooks = %d, want 3", res.AffectedBooks)
+	}
+	if _, err := f.service.GetCategory(ctx, &api.GetCategoryRequest{CategoryId: category.Id}); !errs.Is(err, errs.NotFound) {
+		t.Errorf("GetCategory after delete = %v, want not found", err)
+	}
+}
+
+// A category that changes while book-service closes it is not deleted, and
+// book-service is told to accept books in it again.
+func TestDeleteCategoryReopensWhenDeleteFails(t *testing.T) {
+	f := newCategoryFixture(t)
+	ctx := context.Background()
+	category := f.createCategory(t)
+
+	f.books.onClose = func() {
+		_, err := f.service.UpdateCategory(ctx, &api.UpdateCategoryRequest{
+			Category:        &api.BookCategory{Id: category.Id},
+			UpdateMask:      &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+			ExpectedVersion: category.Version,
+		}, "Renamed "+uuid.New().String(), "")
+		if err != nil {
+			t.Errorf("UpdateCategory while closing: %v", err)
+		}
+	}
+
+	_, err := f.service.DeleteCategory(ctx, &api.DeleteCategoryRequest{CategoryId: category.Id, ExpectedVersion: category.Version})
+	if !errs.Is(err, errs.FailedPrecondition) {
+		t.Fatalf("DeleteCategory = %v, want failed precondition", err)
+	}
+	if _, err := f.service.GetCategory(ctx, &api.GetCategoryRequest{CategoryId: category.Id}); err != nil {
+		t.Errorf("category was deleted at a stale version: %v", err)
+	}
+
+	f.books.mu.Lock()
+	defer f.books.mu.Unlock()
+	if len(f.books.reopened) != 1 || f.books.reopened[0] != category.Id {
+		t.Errorf("reopened = %v, want [%s]", f.books.reopened, category.Id)
+	}
+}
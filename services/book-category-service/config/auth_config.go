@@ -0,0 +1,15 @@
+package config
+
+import (
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/utils"
+)
+
+func NewAuthConfig() auth.Config {
+	return auth.Config{
+		Mode:          utils.GetEnv("AUTH_MODE"),
+		Secret:        utils.GetEnv("JWT_SECRET"),
+		PublicKeyPath: utils.GetEnv("JWT_PUBLIC_KEY_PATH"),
+		Issuer:        utils.GetEnv("JWT_ISSUER"),
+	}
+}
@@ -23,6 +23,7 @@ var (
 	minConns           = utils.GetEnv("DB_MIN_CONNS")
 	maxConns           = utils.GetEnv("DB_MAX_CONNS")
 	TimeOutDuration, _ = strconv.Atoi(utils.GetEnv("DB_CONNECTION_TIMEOUT"))
+	AutoMigrate        = utils.GetEnv("DB_AUTO_MIGRATE") == "true"
 )
 
 func NewPostgresDatabase() *pgxpool.Pool {
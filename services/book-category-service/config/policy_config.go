@@ -0,0 +1,19 @@
+package config
+
+import "github.com/daffaromero/gobook/services/common/auth"
+
+var adminOnly = []string{auth.RoleAdmin}
+
+// AccessRules lists the roles allowed to perform each action. RPC method names
+// apply to gRPC calls and to the matching HTTP routes; ViewMetrics only
+// guards an HTTP route.
+var AccessRules = map[string][]string{
+	"CreateCategory": auth.CatalogWriters,
+	"UpdateCategory": auth.CatalogWriters,
+	"DeleteCategory": auth.CatalogWriters,
+
+	"ListDeletedCategories": adminOnly,
+	"RestoreCategory":       adminOnly,
+	"ListAuditEntries":      adminOnly,
+	"ViewMetrics":           adminOnly,
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/utils"
+)
+
+var (
+	// Deleted categories are purged once they have been in the trash this long.
+	TrashRetention     = time.Duration(intEnv("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour
+	TrashPurgeInterval = time.Duration(intEnv("TRASH_PURGE_INTERVAL_MINUTES", 60)) * time.Minute
+)
+
+func intEnv(key string, fallback int) int {
+	n, err := strconv.Atoi(utils.GetEnv(key))
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
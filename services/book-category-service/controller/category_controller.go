@@ -4,6 +4,12 @@ import (
 	api "github.com/daffaromero/gobook/protobuf/api"
 	"github.com/daffaromero/gobook/services/book-category-service/config"
 	"github.com/daffaromero/gobook/services/book-category-service/service"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/etag"
+	"github.com/daffaromero/gobook/services/common/fieldmask"
+	"github.com/daffaromero/gobook/services/common/pagination"
+	"github.com/daffaromero/gobook/services/common/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
 )
@@ -14,28 +20,40 @@ type CategoryController interface {
 	ListCategories(ctx fiber.Ctx) error
 	CreateCategory(ctx fiber.Ctx) error
 	UpdateCategory(ctx fiber.Ctx) error
+	PatchCategory(ctx fiber.Ctx) error
 	DeleteCategory(ctx fiber.Ctx) error
+	ListDeletedCategories(ctx fiber.Ctx) error
+	RestoreCategory(ctx fiber.Ctx) error
+	ListAuditEntries(ctx fiber.Ctx) error
 }
 
 type categoryController struct {
-	validate *validator.Validate
-	service  service.CategoryService
+	validate     *validator.Validate
+	service      service.CategoryService
+	authenticate fiber.Handler
+	policy       *auth.Policy
 }
 
-func NewCategoryController(validate *validator.Validate, service service.CategoryService) CategoryController {
+func NewCategoryController(validate *validator.Validate, service service.CategoryService, authenticate fiber.Handler, policy *auth.Policy) CategoryController {
 	return &categoryController{
-		validate: validate,
-		service:  service,
+		validate:     validate,
+		service:      service,
+		authenticate: authenticate,
+		policy:       policy,
 	}
 }
 
 func (c *categoryController) Route(app *fiber.App) {
 	api := app.Group(config.EndpointPrefix)
+	api.Get("/trash", c.ListDeletedCategories, c.authenticate, c.policy.Require("ListDeletedCategories"))
+	api.Post("/trash/:id/restore", c.RestoreCategory, c.authenticate, c.policy.Require("RestoreCategory"))
+	api.Get("/audit", c.ListAuditEntries, c.authenticate, c.policy.Require("ListAuditEntries"))
 	api.Get("/:id", c.GetCategory)
 	api.Get("/", c.ListCategories)
-	api.Post("/new", c.CreateCategory)
-	api.Put("/:id", c.UpdateCategory)
-	api.Delete("/:id", c.DeleteCategory)
+	api.Post("/new", c.CreateCategory, c.authenticate, c.policy.Require("CreateCategory"))
+	api.Put("/:id", c.UpdateCategory, c.authenticate, c.policy.Require("UpdateCategory"), etag.RequireIfMatch)
+	api.Patch("/:id", c.PatchCategory, c.authenticate, c.policy.Require("UpdateCategory"), etag.RequireIfMatch)
+	api.Delete("/:id", c.DeleteCategory, c.authenticate, c.policy.Require("DeleteCategory"), etag.RequireIfMatch)
 }
 
 func (c *categoryController) GetCategory(ctx fiber.Ctx) error {
@@ -47,18 +65,28 @@ func (c *categoryController) GetCategory(ctx fiber.Ctx) error {
 
 	res, err := c.service.GetCategory(ctx.Context(), &req)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
 	}
+	etag.Set(ctx, res.Category.GetVersion())
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
 }
 
 func (c *categoryController) ListCategories(ctx fiber.Ctx) error {
-	var req *api.ListCategoriesRequest
+	page, sorting, search, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &api.ListCategoriesRequest{
+		Pagination: page,
+		Sorting:    sorting,
+		Search:     search,
+	}
 
 	res, err := c.service.ListCategories(ctx.Context(), req)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
@@ -79,13 +107,18 @@ func (c *categoryController) CreateCategory(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "category is required"})
 	}
 
+	if req.Category.Name == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "category name is required"})
+	}
+
 	name := req.Category.Name
 	description := req.Category.Description
 
 	res, err := c.service.CreateCategory(ctx.Context(), &req, name, description)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
 	}
+	etag.Set(ctx, res.Category.GetVersion())
 
 	return ctx.Status(fiber.StatusCreated).JSON(res)
 }
@@ -110,18 +143,60 @@ func (c *categoryController) UpdateCategory(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	if req.Category.Name == "" && req.Category.Description == "" {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "At least one field (name or description) must be provided for update"})
+	req.ExpectedVersion = etag.Version(ctx)
+
+	res, err := c.service.UpdateCategory(ctx.Context(), &req, req.Category.Name, req.Category.Description)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+	etag.Set(ctx, res.Category.GetVersion())
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// PatchCategory writes only the fields present in the request body, unless
+// the body carries its own update_mask.
+func (c *categoryController) PatchCategory(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "category_id not provided"})
 	}
 
+	var req api.UpdateCategoryRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.Category == nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "category cannot be empty"})
+	}
+	req.Category.Id = id
+
+	if req.UpdateMask == nil {
+		mask, err := fieldmask.FromBody(ctx.Body(), "category")
+		if err != nil {
+			return errs.Respond(ctx, err)
+		}
+		req.UpdateMask = mask
+	}
+
+	req.ExpectedVersion = etag.Version(ctx)
+
 	res, err := c.service.UpdateCategory(ctx.Context(), &req, req.Category.Name, req.Category.Description)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
 	}
+	etag.Set(ctx, res.Category.GetVersion())
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
 }
 
+var deleteModes = map[string]api.DeleteCategoryMode{
+	"refuse":   api.DeleteCategoryMode_DELETE_CATEGORY_MODE_REFUSE,
+	"reassign": api.DeleteCategoryMode_DELETE_CATEGORY_MODE_REASSIGN,
+	"cascade":  api.DeleteCategoryMode_DELETE_CATEGORY_MODE_CASCADE,
+}
+
 func (c *categoryController) DeleteCategory(ctx fiber.Ctx) error {
 	var req api.DeleteCategoryRequest
 	req.CategoryId = ctx.Params("id")
@@ -129,9 +204,84 @@ func (c *categoryController) DeleteCategory(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "category_id not provided"})
 	}
 
+	mode, ok := deleteModes[ctx.Query("mode", "refuse")]
+	if !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "mode must be one of refuse, reassign or cascade"})
+	}
+	req.Mode = mode
+	req.FallbackCategoryId = ctx.Query("fallback_id")
+	req.ExpectedVersion = etag.Version(ctx)
+
+	if req.Mode == api.DeleteCategoryMode_DELETE_CATEGORY_MODE_REASSIGN && req.FallbackCategoryId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "fallback_id is required to reassign books"})
+	}
+
 	res, err := c.service.DeleteCategory(ctx.Context(), &req)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *categoryController) ListDeletedCategories(ctx fiber.Ctx) error {
+	page, _, _, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := c.service.ListDeletedCategories(ctx.Context(), &api.ListDeletedCategoriesRequest{Pagination: page})
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *categoryController) RestoreCategory(ctx fiber.Ctx) error {
+	var req api.RestoreCategoryRequest
+	req.CategoryId = ctx.Params("id")
+	if req.CategoryId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "category_id not provided"})
+	}
+
+	res, err := c.service.RestoreCategory(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+	etag.Set(ctx, res.Category.GetVersion())
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// ListAuditEntries filters the audit log by entity_id, actor_id and a
+// since/until range of RFC 3339 timestamps.
+func (c *categoryController) ListAuditEntries(ctx fiber.Ctx) error {
+	page, _, _, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	since, err := utils.TimeQuery(ctx, "since")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	until, err := utils.TimeQuery(ctx, "until")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &api.ListAuditEntriesRequest{
+		EntityId:   ctx.Query("entity_id"),
+		ActorId:    ctx.Query("actor_id"),
+		Since:      since,
+		Until:      until,
+		Pagination: page,
+	}
+
+	res, err := c.service.ListAuditEntries(ctx.Context(), req)
+	if err != nil {
+		return errs.Respond(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
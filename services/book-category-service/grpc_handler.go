@@ -7,6 +7,8 @@ import (
 	api "github.com/daffaromero/gobook/protobuf/api"
 	"github.com/daffaromero/gobook/services/book-category-service/service"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type CategoryGRPCHandler struct {
@@ -41,3 +43,79 @@ func (h *CategoryGRPCHandler) ListCategories(ctx context.Context, req *api.ListC
 
 	return res, nil
 }
+
+func (h *CategoryGRPCHandler) CreateCategory(ctx context.Context, req *api.CreateCategoryRequest) (*api.CreateCategoryResponse, error) {
+	if req.Category == nil {
+		return nil, status.Error(codes.InvalidArgument, "category is required")
+	}
+	if req.Category.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "category name is required")
+	}
+
+	res, err := h.service.CreateCategory(ctx, req, req.Category.Name, req.Category.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *CategoryGRPCHandler) UpdateCategory(ctx context.Context, req *api.UpdateCategoryRequest) (*api.UpdateCategoryResponse, error) {
+	if req.Category == nil {
+		return nil, status.Error(codes.InvalidArgument, "category cannot be empty")
+	}
+	if req.Category.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "category_id not provided")
+	}
+	if req.ExpectedVersion <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version not provided")
+	}
+
+	res, err := h.service.UpdateCategory(ctx, req, req.Category.Name, req.Category.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *CategoryGRPCHandler) DeleteCategory(ctx context.Context, req *api.DeleteCategoryRequest) (*api.DeleteCategoryResponse, error) {
+	if req.CategoryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "category_id not provided")
+	}
+
+	if req.ExpectedVersion <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version not provided")
+	}
+
+	if req.Mode == api.DeleteCategoryMode_DELETE_CATEGORY_MODE_REASSIGN && req.FallbackCategoryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "fallback_category_id is required to reassign books")
+	}
+
+	res, err := h.service.DeleteCategory(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *CategoryGRPCHandler) ListDeletedCategories(ctx context.Context, req *api.ListDeletedCategoriesRequest) (*api.ListDeletedCategoriesResponse, error) {
+	return h.service.ListDeletedCategories(ctx, req)
+}
+
+func (h *CategoryGRPCHandler) RestoreCategory(ctx context.Context, req *api.RestoreCategoryRequest) (*api.RestoreCategoryResponse, error) {
+	if req.CategoryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "category_id not provided")
+	}
+
+	return h.service.RestoreCategory(ctx, req)
+}
+
+func (h *CategoryGRPCHandler) ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error) {
+	return h.service.ListAuditEntries(ctx, req)
+}
+
+func (h *CategoryGRPCHandler) WatchCategories(req *api.WatchCategoriesRequest, stream api.BookCategoryService_WatchCategoriesServer) error {
+	return h.service.WatchCategories(stream.Context(), req, stream.Send)
+}
@@ -12,15 +12,22 @@ import (
 
 	"github.com/daffaromero/gobook/services/book-category-service/config"
 	"github.com/daffaromero/gobook/services/book-category-service/controller"
+	"github.com/daffaromero/gobook/services/book-category-service/migrations"
 	"github.com/daffaromero/gobook/services/book-category-service/repository"
 	"github.com/daffaromero/gobook/services/book-category-service/repository/query"
 	"github.com/daffaromero/gobook/services/book-category-service/service"
+	"github.com/daffaromero/gobook/services/common/audit"
+	"github.com/daffaromero/gobook/services/common/auth"
 	"github.com/daffaromero/gobook/services/common/discovery"
 	"github.com/daffaromero/gobook/services/common/discovery/consul"
+	"github.com/daffaromero/gobook/services/common/errs"
 	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/daffaromero/gobook/services/common/migrate"
+	"github.com/daffaromero/gobook/services/common/outbox"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/gofiber/fiber/v3/middleware/expvar"
 	"github.com/gofiber/fiber/v3/middleware/requestid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
@@ -31,10 +38,23 @@ var logs = logger.New("main")
 func webServer() error {
 	app := fiber.New()
 	app.Use(requestid.New())
+	app.Use(audit.Middleware())
 
 	serverConfig := config.NewServerConfig()
 	dbConfig := config.NewPostgresDatabase()
 	store := repository.NewStore(dbConfig)
+
+	if config.AutoMigrate {
+		migrator, err := migrate.New(dbConfig, migrations.FS)
+		if err != nil {
+			logs.Error(fmt.Sprintf("Failed to load migrations: %v", err))
+			return err
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			logs.Error(fmt.Sprintf("Failed to apply migrations: %v", err))
+			return err
+		}
+	}
 	validate := validator.New()
 
 	registry, err := consul.NewRegistry(serverConfig.ConsulAddr, serverConfig.Name)
@@ -104,18 +124,47 @@ func webServer() error {
 	defer registry.DeregisterService(ctx, HTTPserviceID)
 
 	categoryQuery := query.NewCategoryQuery(dbConfig)
-	categoryRepo := repository.NewCategoryRepository(store, categoryQuery)
-	categoryService := service.NewCategoryService(categoryRepo, logs)
-	categoryController := controller.NewCategoryController(validate, categoryService)
+	auditQuery := audit.NewQuery(dbConfig)
+	feed := outbox.NewFeed(dbConfig, logs)
+	go feed.Run(ctx, config.OutboxRelayInterval)
+	categoryRepo := repository.NewCategoryRepository(store, categoryQuery, auditQuery, feed)
+	categoryService := service.NewCategoryService(registry, categoryRepo, logs)
+
+	go func() {
+		// Permanently remove categories that have been in the trash too long.
+		ticker := time.NewTicker(config.TrashPurgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			categoryService.PurgeCategories(ctx, config.TrashRetention)
+		}
+	}()
+
+	publisher, err := outbox.NewPublisher(config.OutboxPublisher, config.OutboxURL(), logs)
+	if err != nil {
+		logs.Error(fmt.Sprintf("Failed to create outbox publisher: %v", err))
+		return err
+	}
+	relay := outbox.NewRelay(dbConfig, publisher, logs, config.OutboxBatchSize, config.OutboxRetention)
+	go relay.Run(ctx, config.OutboxRelayInterval)
+
+	tokenValidator, err := auth.NewValidator(ctx, config.NewAuthConfig(), registry)
+	if err != nil {
+		logs.Error(fmt.Sprintf("Failed to create token validator: %v", err))
+		return err
+	}
+	policy := auth.NewPolicy(config.AccessRules)
+	categoryController := controller.NewCategoryController(validate, categoryService, auth.Middleware(tokenValidator), policy)
 
 	go func() {
 		// gRPC server + reflection
-		grpcServer := grpc.NewServer()
+		grpcServer := newGRPCServer(tokenValidator, policy)
 		reflection.Register(grpcServer)
 
 		l, err := net.Listen("tcp", serverConfig.GRPC)
 		if err != nil {
+			// The service cannot do without its gRPC API, so stop it here.
 			logs.Error(fmt.Sprintf("Failed to listen: %v", err))
+			os.Exit(1)
 		}
 		logs.Log(fmt.Sprintf("gRPC server started on %s", serverConfig.GRPC))
 		defer l.Close()
@@ -129,8 +178,11 @@ func webServer() error {
 
 	// HTTP server (Fiber)
 	logs.Log(fmt.Sprintf("Starting HTTP category server on %s", serverConfig.HTTP))
-	app.Use(cors.New())
+	// Browsers only let scripts read the ETag needed for If-Match if it is exposed.
+	app.Use(cors.New(cors.Config{ExposeHeaders: []string{fiber.HeaderETag}}))
 	categoryController.Route(app)
+	// Runtime stats and the state of each gRPC client circuit breaker.
+	app.Get("/debug/vars", expvar.New(), auth.Middleware(tokenValidator), policy.Require("ViewMetrics"))
 
 	err = app.Listen(serverConfig.HTTP, fiber.ListenConfig{
 		DisableStartupMessage: true,
@@ -143,7 +195,40 @@ func webServer() error {
 	return nil
 }
 
+// newGRPCServer returns a gRPC server that authenticates callers with tokens
+// and enforces policy on every call.
+func newGRPCServer(tokens auth.Validator, policy *auth.Policy) *grpc.Server {
+	return grpc.NewServer(grpc.ChainUnaryInterceptor(
+		errs.UnaryServerInterceptor(),
+		audit.UnaryServerInterceptor(),
+		auth.UnaryServerInterceptor(tokens),
+		policy.UnaryServerInterceptor(),
+	), grpc.ChainStreamInterceptor(
+		errs.StreamServerInterceptor(),
+		auth.StreamServerInterceptor(tokens),
+		policy.StreamServerInterceptor(),
+	))
+}
+
+// migrateCommand handles "migrate up|down [steps]|status" without starting
+// the servers.
+func migrateCommand(args []string) error {
+	migrator, err := migrate.New(config.NewPostgresDatabase(), migrations.FS)
+	if err != nil {
+		return err
+	}
+	return migrator.Run(context.Background(), args, os.Stdout)
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrateCommand(os.Args[2:]); err != nil {
+			logs.Error(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := webServer(); err != nil {
 		logs.Error(err)
 	}
@@ -1,4 +1,4 @@
-CREATE TABLE book_categories (
+CREATE TABLE IF NOT EXISTS book_categories (
   "id" uuid PRIMARY KEY,
   "name" VARCHAR(255) NOT NULL,
   "description" TEXT DEFAULT '-',
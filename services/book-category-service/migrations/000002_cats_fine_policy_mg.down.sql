@@ -0,0 +1,5 @@
+ALTER TABLE book_categories
+  DROP CONSTRAINT IF EXISTS book_categories_fine_policy_check,
+  DROP COLUMN IF EXISTS "fine_cap_cents",
+  DROP COLUMN IF EXISTS "fine_grace_days",
+  DROP COLUMN IF EXISTS "fine_daily_rate_cents";
@@ -0,0 +1,8 @@
+ALTER TABLE book_categories
+  ADD COLUMN IF NOT EXISTS "fine_daily_rate_cents" BIGINT DEFAULT NULL,
+  ADD COLUMN IF NOT EXISTS "fine_grace_days" INTEGER DEFAULT NULL,
+  ADD COLUMN IF NOT EXISTS "fine_cap_cents" BIGINT DEFAULT NULL,
+  ADD CONSTRAINT book_categories_fine_policy_check CHECK (
+    (fine_daily_rate_cents IS NULL AND fine_grace_days IS NULL AND fine_cap_cents IS NULL) OR
+    (fine_daily_rate_cents >= 0 AND fine_grace_days >= 0 AND fine_cap_cents >= 0)
+  );
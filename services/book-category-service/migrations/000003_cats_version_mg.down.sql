@@ -0,0 +1 @@
+ALTER TABLE book_categories DROP COLUMN IF EXISTS "version";
@@ -0,0 +1 @@
+ALTER TABLE book_categories ADD COLUMN IF NOT EXISTS "version" BIGINT NOT NULL DEFAULT 1;
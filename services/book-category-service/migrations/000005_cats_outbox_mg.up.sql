@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS outbox (
+  "id" uuid PRIMARY KEY,
+  "topic" VARCHAR(64) NOT NULL,
+  "aggregate_id" uuid NOT NULL,
+  "request_id" TEXT NOT NULL DEFAULT '',
+  "payload" JSONB NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "last_error" TEXT DEFAULT NULL,
+  "next_attempt_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "published_at" TIMESTAMPTZ DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (created_at, id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_pending_aggregate_idx ON outbox (aggregate_id, next_attempt_at) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS outbox_sequence_idx;
+ALTER TABLE outbox DROP COLUMN IF EXISTS "sequence";
+DROP SEQUENCE IF EXISTS outbox_sequence;
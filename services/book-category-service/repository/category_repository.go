@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	api "github.com/daffaromero/gobook/protobuf/api"
 	"github.com/daffaromero/gobook/services/book-category-service/repository/query"
+	"github.com/daffaromero/gobook/services/common/audit"
+	"github.com/daffaromero/gobook/services/common/outbox"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -16,20 +19,56 @@ type CategoryRepository interface {
 	CreateCategory(ctx context.Context, req *api.CreateCategoryRequest) (*api.CreateCategoryResponse, error)
 	UpdateCategory(ctx context.Context, req *api.UpdateCategoryRequest) (*api.UpdateCategoryResponse, error)
 	DeleteCategory(ctx context.Context, id *api.DeleteCategoryRequest) (*api.DeleteCategoryResponse, error)
+	ListDeletedCategories(ctx context.Context, req *api.ListDeletedCategoriesRequest) (*api.ListDeletedCategoriesResponse, error)
+	RestoreCategory(ctx context.Context, req *api.RestoreCategoryRequest) (*api.RestoreCategoryResponse, error)
+	PurgeCategories(ctx context.Context, before time.Time) (int64, error)
+	ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error)
+	WatchCategories(ctx context.Context, afterSequence int64, send func(outbox.Event) error) error
 }
 
 type categoryRepository struct {
 	db            Store
 	categoryQuery query.CategoryQuery
+	auditQuery    audit.Query
+	feed          *outbox.Feed
 }
 
-func NewCategoryRepository(db Store, categoryQuery query.CategoryQuery) CategoryRepository {
+func NewCategoryRepository(db Store, categoryQuery query.CategoryQuery, auditQuery audit.Query, feed *outbox.Feed) CategoryRepository {
 	return &categoryRepository{
 		db:            db,
 		categoryQuery: categoryQuery,
+		auditQuery:    auditQuery,
+		feed:          feed,
 	}
 }
 
+// record appends an audit entry for a change to a category within tx, and
+// queues the matching event for the outbox relay. before is nil for creates
+// and restores, after for deletes.
+func (r *categoryRepository) record(ctx context.Context, tx pgx.Tx, action string, before, after *api.BookCategory) error {
+	id, state := after.GetId(), after
+	if before != nil {
+		id = before.Id
+	}
+	if after == nil {
+		state = before
+	}
+
+	entry, err := audit.NewEntry(ctx, query.EntityCategory, id, action, before, after)
+	if err != nil {
+		return fmt.Errorf("failed to build audit entry: %w", err)
+	}
+	if err := r.auditQuery.Record(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	event, err := outbox.NewEvent(ctx, outbox.Topic(query.EntityCategory, action), id, state)
+	if err != nil {
+		return err
+	}
+	return outbox.Enqueue(ctx, tx, event)
+}
+
 func (r *categoryRepository) GetCategory(ctx context.Context, req *api.GetCategoryRequest) (*api.GetCategoryResponse, error) {
 	var category *api.GetCategoryResponse
 
@@ -64,7 +103,10 @@ func (r *categoryRepository) CreateCategory(ctx context.Context, req *api.Create
 	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
 		var err error
 		category, err = r.categoryQuery.CreateCategory(ctx, tx, req)
-		return err
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, tx, audit.Create, nil, category.Category)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create category: %w", err)
@@ -76,9 +118,15 @@ func (r *categoryRepository) UpdateCategory(ctx context.Context, req *api.Update
 	var category *api.UpdateCategoryResponse
 
 	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
-		var err error
+		before, err := r.categoryQuery.LockCategory(ctx, tx, req.GetCategory().GetId())
+		if err != nil {
+			return err
+		}
 		category, err = r.categoryQuery.UpdateCategory(ctx, tx, req)
-		return err
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, tx, audit.Update, before, category.Category)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update category: %w", err)
@@ -90,12 +138,83 @@ func (r *categoryRepository) DeleteCategory(ctx context.Context, req *api.Delete
 	var res *api.DeleteCategoryResponse
 
 	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
-		var err error
+		before, err := r.categoryQuery.LockCategory(ctx, tx, req.CategoryId)
+		if err != nil {
+			return err
+		}
 		res, err = r.categoryQuery.DeleteCategory(ctx, tx, req)
-		return err
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, tx, audit.Delete, before, nil)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete category: %w", err)
 	}
 	return res, nil
 }
+
+func (r *categoryRepository) ListDeletedCategories(ctx context.Context, req *api.ListDeletedCategoriesRequest) (*api.ListDeletedCategoriesResponse, error) {
+	var categories *api.ListDeletedCategoriesResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		categories, err = r.categoryQuery.ListDeletedCategories(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted categories: %w", err)
+	}
+	return categories, nil
+}
+
+func (r *categoryRepository) RestoreCategory(ctx context.Context, req *api.RestoreCategoryRequest) (*api.RestoreCategoryResponse, error) {
+	var res *api.RestoreCategoryResponse
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.categoryQuery.RestoreCategory(ctx, tx, req)
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, tx, audit.Restore, nil, res.Category)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore category: %w", err)
+	}
+	return res, nil
+}
+
+func (r *categoryRepository) PurgeCategories(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		purged, err = r.categoryQuery.PurgeCategories(ctx, tx, before)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge categories: %w", err)
+	}
+	return purged, nil
+}
+
+func (r *categoryRepository) ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error) {
+	var entries *api.ListAuditEntriesResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		entries, err = r.auditQuery.ListAuditEntries(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// WatchCategories follows the events recorded with each change. It holds no
+// transaction, as it may run for as long as the caller stays connected.
+func (r *categoryRepository) WatchCategories(ctx context.Context, afterSequence int64, send func(outbox.Event) error) error {
+	return r.feed.Watch(ctx, afterSequence, send)
+}
@@ -4,19 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/pagination"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// EntityCategory is the audit log entity type for categories.
+const EntityCategory = "category"
+
 type CategoryQuery interface {
 	GetCategory(ctx context.Context, req *api.GetCategoryRequest) (*api.GetCategoryResponse, error)
 	ListCategories(ctx context.Context, req *api.ListCategoriesRequest) (*api.ListCategoriesResponse, error)
 	CreateCategory(ctx context.Context, tx pgx.Tx, req *api.CreateCategoryRequest) (*api.CreateCategoryResponse, error)
 	UpdateCategory(ctx context.Context, tx pgx.Tx, req *api.UpdateCategoryRequest) (*api.UpdateCategoryResponse, error)
 	DeleteCategory(ctx context.Context, tx pgx.Tx, id *api.DeleteCategoryRequest) (*api.DeleteCategoryResponse, error)
+	ListDeletedCategories(ctx context.Context, req *api.ListDeletedCategoriesRequest) (*api.ListDeletedCategoriesResponse, error)
+	RestoreCategory(ctx context.Context, tx pgx.Tx, req *api.RestoreCategoryRequest) (*api.RestoreCategoryResponse, error)
+	PurgeCategories(ctx context.Context, tx pgx.Tx, before time.Time) (int64, error)
+	LockCategory(ctx context.Context, tx pgx.Tx, id string) (*api.BookCategory, error)
 }
 
 type categoryQuery struct {
@@ -29,32 +40,96 @@ func NewCategoryQuery(db *pgxpool.Pool) *categoryQuery {
 	}
 }
 
+// fineColumns are the nullable fine policy columns read by scanFinePolicy.
+const fineColumns = `fine_daily_rate_cents, fine_grace_days, fine_cap_cents`
+
+// finePolicy holds the scan targets for fineColumns.
+type finePolicy struct {
+	dailyRate *int64
+	graceDays *int32
+	capCents  *int64
+}
+
+func (f *finePolicy) dest() []any {
+	return []any{&f.dailyRate, &f.graceDays, &f.capCents}
+}
+
+// policy returns nil when the category uses the default fine rules.
+func (f *finePolicy) policy() *api.FinePolicy {
+	if f.dailyRate == nil || f.graceDays == nil || f.capCents == nil {
+		return nil
+	}
+	return &api.FinePolicy{
+		DailyRateCents: *f.dailyRate,
+		GraceDays:      *f.graceDays,
+		CapCents:       *f.capCents,
+	}
+}
+
+// fineArgs turns a policy into column values; nil clears the columns.
+func fineArgs(p *api.FinePolicy) (dailyRate *int64, graceDays *int32, capCents *int64) {
+	if p == nil {
+		return nil, nil, nil
+	}
+	return &p.DailyRateCents, &p.GraceDays, &p.CapCents
+}
+
 func (q *categoryQuery) GetCategory(ctx context.Context, req *api.GetCategoryRequest) (*api.GetCategoryResponse, error) {
 	if req == nil || req.CategoryId == "" {
-		return nil, errors.New("category ID cannot be empty")
+		return nil, errs.New(errs.InvalidArgument, "category ID cannot be empty")
 	}
-	query := `SELECT id, name, description FROM book_categories WHERE id = $1 AND deleted_at IS NULL`
+	query := `SELECT id, name, description, version, ` + fineColumns + ` FROM book_categories WHERE id = $1 AND deleted_at IS NULL`
 
 	row := q.db.QueryRow(ctx, query, req.CategoryId)
 
 	var category api.BookCategory
-	err := row.Scan(&category.Id, &category.Name, &category.Description)
+	var fines finePolicy
+	err := row.Scan(append([]any{&category.Id, &category.Name, &category.Description, &category.Version}, fines.dest()...)...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("category with ID %s not found", req.CategoryId)
+			return nil, errs.Newf(errs.NotFound, "category with ID %s not found", req.CategoryId)
 		}
-		return nil, fmt.Errorf("failed to scan category: %w", err)
+		return nil, fmt.Errorf("failed to scan category: %w", errs.DB(err))
 	}
+	category.FinePolicy = fines.policy()
 
 	return &api.GetCategoryResponse{
 		Category: &category,
 	}, nil
 }
 
+var categorySortColumns = map[string]string{
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
 func (q *categoryQuery) ListCategories(ctx context.Context, req *api.ListCategoriesRequest) (*api.ListCategoriesResponse, error) {
-	query := `SELECT id, name, description FROM book_categories WHERE deleted_at IS NULL`
+	orderBy, err := pagination.OrderBy(req.Sorting, categorySortColumns, "name")
+	if err != nil {
+		return nil, err
+	}
+	limit, offset, err := pagination.Resolve(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	where := `WHERE deleted_at IS NULL`
+	args := []any{}
+	if req.Search != "" {
+		args = append(args, pagination.SearchPattern(req.Search))
+		where += ` AND name ILIKE $1`
+	}
+
+	var total int64
+	if err := q.db.QueryRow(ctx, `SELECT COUNT(*) FROM book_categories `+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count categories: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT id, name, description, version, %s FROM book_categories %s ORDER BY %s LIMIT $%d OFFSET $%d`, fineColumns, where, orderBy, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
 
-	rows, err := q.db.Query(ctx, query)
+	rows, err := q.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query categories: %w", err)
 	}
@@ -63,34 +138,51 @@ func (q *categoryQuery) ListCategories(ctx context.Context, req *api.ListCategor
 	var categories []*api.BookCategory
 	for rows.Next() {
 		var category api.BookCategory
-		err := rows.Scan(&category.Id, &category.Name, &category.Description)
+		var fines finePolicy
+		err := rows.Scan(append([]any{&category.Id, &category.Name, &category.Description, &category.Version}, fines.dest()...)...)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan category: %w", err)
+			return nil, fmt.Errorf("failed to scan category: %w", errs.DB(err))
 		}
+		category.FinePolicy = fines.policy()
 		categories = append(categories, &category)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read categories: %w", err)
+	}
+
 	return &api.ListCategoriesResponse{
 		Categories: categories,
+		PageInfo: &api.PageInfo{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
 	}, nil
 }
 
 func (q *categoryQuery) CreateCategory(ctx context.Context, tx pgx.Tx, req *api.CreateCategoryRequest) (*api.CreateCategoryResponse, error) {
 	if req == nil || req.Category == nil {
-		return nil, errors.New("request cannot be nil")
+		return nil, errs.New(errs.InvalidArgument, "request cannot be nil")
 	}
 
-	query := `INSERT INTO book_categories (id, name, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, name, description`
+	query := `INSERT INTO book_categories (id, name, description, created_at, updated_at, ` + fineColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, name, description, version, ` + fineColumns
 
 	createdAt := req.Category.CreatedAt.AsTime()
 	updatedAt := req.Category.UpdatedAt.AsTime()
+	dailyRate, graceDays, capCents := fineArgs(req.Category.FinePolicy)
 
 	var createdCategory api.BookCategory
+	var fines finePolicy
 
-	err := tx.QueryRow(ctx, query, req.Category.Id, req.Category.Name, req.Category.Description, createdAt, updatedAt).Scan(&createdCategory.Id, &createdCategory.Name, &createdCategory.Description)
+	err := tx.QueryRow(ctx, query, req.Category.Id, req.Category.Name, req.Category.Description, createdAt, updatedAt, dailyRate, graceDays, capCents).
+		Scan(append([]any{&createdCategory.Id, &createdCategory.Name, &createdCategory.Description, &createdCategory.Version}, fines.dest()...)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert category: %w", err)
+		return nil, fmt.Errorf("failed to insert category: %w", errs.DB(err))
 	}
+	createdCategory.FinePolicy = fines.policy()
 
 	return &api.CreateCategoryResponse{
 		Category: &createdCategory,
@@ -99,56 +191,204 @@ func (q *categoryQuery) CreateCategory(ctx context.Context, tx pgx.Tx, req *api.
 
 func (q *categoryQuery) UpdateCategory(ctx context.Context, tx pgx.Tx, req *api.UpdateCategoryRequest) (*api.UpdateCategoryResponse, error) {
 	if req == nil || req.Category == nil {
-		return nil, errors.New("request and category cannot be nil")
+		return nil, errs.New(errs.InvalidArgument, "request and category cannot be nil")
 	}
 	if req.Category.Id == "" {
-		return nil, errors.New("category ID cannot be empty")
+		return nil, errs.New(errs.InvalidArgument, "category ID cannot be empty")
 	}
 
-	query := `UPDATE book_categories 
-		SET 
-			name = COALESCE($2, name),
-			description = COALESCE($3, description),
-			updated_at = $4
-		WHERE id = $1 AND deleted_at IS NULL
-		RETURNING id, name, description, updated_at`
+	if len(req.UpdateMask.GetPaths()) == 0 {
+		return nil, errs.New(errs.InvalidArgument, "update mask cannot be empty")
+	}
 
 	updatedAt := time.Now()
 	if req.Category.UpdatedAt != nil {
 		updatedAt = req.Category.UpdatedAt.AsTime()
 	}
 
+	// Only the columns named by the update mask are written. A masked but
+	// unset fine policy clears the columns back to the defaults.
+	args := []any{req.Category.Id, updatedAt, req.ExpectedVersion}
+	sets := []string{"updated_at = $2", "version = version + 1"}
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	for _, path := range req.UpdateMask.Paths {
+		switch path {
+		case "name":
+			set("name", req.Category.Name)
+		case "description":
+			set("description", req.Category.Description)
+		case "fine_policy":
+			dailyRate, graceDays, capCents := fineArgs(req.Category.FinePolicy)
+			set("fine_daily_rate_cents", dailyRate)
+			set("fine_grace_days", graceDays)
+			set("fine_cap_cents", capCents)
+		default:
+			return nil, errs.Newf(errs.InvalidArgument, "invalid update mask: field %q cannot be updated", path)
+		}
+	}
+
+	query := fmt.Sprintf(`UPDATE book_categories SET %s
+		WHERE id = $1 AND deleted_at IS NULL AND version = $3
+		RETURNING id, name, description, updated_at, version, %s`, strings.Join(sets, ", "), fineColumns)
+
 	var updatedCategory api.BookCategory
+	var fines finePolicy
 
-	err := tx.QueryRow(ctx, query, req.Category.Id, req.Category.Name, req.Category.Description, updatedAt).Scan(&updatedCategory.Id, &updatedCategory.Name, &updatedCategory.Description, &updatedAt)
+	err := tx.QueryRow(ctx, query, args...).
+		Scan(append([]any{&updatedCategory.Id, &updatedCategory.Name, &updatedCategory.Description, &updatedAt, &updatedCategory.Version}, fines.dest()...)...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("category with ID %s not found", req.Category.Id)
+			return nil, staleCategory(ctx, tx, req.Category.Id, req.ExpectedVersion)
 		}
-		return nil, fmt.Errorf("failed to update category: %w", err)
+		return nil, fmt.Errorf("failed to update category: %w", errs.DB(err))
 	}
+	updatedCategory.FinePolicy = fines.policy()
 
 	return &api.UpdateCategoryResponse{
 		Category: &updatedCategory,
 	}, nil
 }
 
+// staleCategory explains why a versioned write to a category matched no row:
+// the category is gone, or it has changed since the caller read it.
+func staleCategory(ctx context.Context, tx pgx.Tx, id string, expected int64) error {
+	var version int64
+	err := tx.QueryRow(ctx, `SELECT version FROM book_categories WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.Newf(errs.NotFound, "category with ID %s not found", id)
+		}
+		return errs.DB(err)
+	}
+	return errs.Newf(errs.FailedPrecondition, "category with ID %s has been modified: version is %d, not %d", id, version, expected)
+}
+
 func (q *categoryQuery) DeleteCategory(ctx context.Context, tx pgx.Tx, req *api.DeleteCategoryRequest) (*api.DeleteCategoryResponse, error) {
 	if req.CategoryId == "" {
-		return nil, errors.New("category ID cannot be empty")
+		return nil, errs.New(errs.InvalidArgument, "category ID cannot be empty")
 	}
 
-	query := `UPDATE book_categories SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`
+	query := `UPDATE book_categories SET deleted_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND version = $3`
 
-	_, err := tx.Exec(ctx, query, req.CategoryId, time.Now())
+	tag, err := tx.Exec(ctx, query, req.CategoryId, time.Now(), req.ExpectedVersion)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("category with ID %s not found", req.CategoryId)
-		}
-		return nil, fmt.Errorf("failed to delete category: %w", err)
+		return nil, fmt.Errorf("failed to delete category: %w", errs.DB(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, staleCategory(ctx, tx, req.CategoryId, req.ExpectedVersion)
 	}
 
 	return &api.DeleteCategoryResponse{
 		Success: true,
 	}, nil
 }
+
+func (q *categoryQuery) ListDeletedCategories(ctx context.Context, req *api.ListDeletedCategoriesRequest) (*api.ListDeletedCategoriesResponse, error) {
+	limit, offset, err := pagination.Resolve(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := q.db.QueryRow(ctx, `SELECT COUNT(*) FROM book_categories WHERE deleted_at IS NOT NULL`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count deleted categories: %w", err)
+	}
+
+	query := `SELECT id, name, description, deleted_at, ` + fineColumns + ` FROM book_categories
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+		LIMIT $1 OFFSET $2`
+
+	rows, err := q.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []*api.BookCategory
+	for rows.Next() {
+		var category api.BookCategory
+		var fines finePolicy
+		var deletedAt time.Time
+		if err := rows.Scan(append([]any{&category.Id, &category.Name, &category.Description, &deletedAt}, fines.dest()...)...); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		category.DeletedAt = timestamppb.New(deletedAt)
+		category.FinePolicy = fines.policy()
+		categories = append(categories, &category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read deleted categories: %w", err)
+	}
+
+	return &api.ListDeletedCategoriesResponse{
+		Categories: categories,
+		PageInfo: &api.PageInfo{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
+	}, nil
+}
+
+func (q *categoryQuery) RestoreCategory(ctx context.Context, tx pgx.Tx, req *api.RestoreCategoryRequest) (*api.RestoreCategoryResponse, error) {
+	if req.CategoryId == "" {
+		return nil, errs.New(errs.InvalidArgument, "category ID cannot be empty")
+	}
+
+	query := `UPDATE book_categories SET deleted_at = NULL, updated_at = $2, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, name, description, version, ` + fineColumns
+
+	var category api.BookCategory
+	var fines finePolicy
+	err := tx.QueryRow(ctx, query, req.CategoryId, time.Now()).
+		Scan(append([]any{&category.Id, &category.Name, &category.Description, &category.Version}, fines.dest()...)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "deleted category with ID %s not found", req.CategoryId)
+		}
+		return nil, fmt.Errorf("failed to restore category: %w", errs.DB(err))
+	}
+	category.FinePolicy = fines.policy()
+
+	return &api.RestoreCategoryResponse{
+		Category: &category,
+	}, nil
+}
+
+// PurgeCategories permanently removes categories deleted before the cutoff.
+func (q *categoryQuery) PurgeCategories(ctx context.Context, tx pgx.Tx, before time.Time) (int64, error) {
+	tag, err := tx.Exec(ctx, `DELETE FROM book_categories WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge categories: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// LockCategory reads a live category and locks it until tx ends. It reads
+// the same fields UpdateCategory returns, so the two can be diffed.
+func (q *categoryQuery) LockCategory(ctx context.Context, tx pgx.Tx, id string) (*api.BookCategory, error) {
+	if id == "" {
+		return nil, errs.New(errs.InvalidArgument, "category ID cannot be empty")
+	}
+
+	query := `SELECT id, name, description, version, ` + fineColumns + ` FROM book_categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	var category api.BookCategory
+	var fines finePolicy
+	err := tx.QueryRow(ctx, query, id).Scan(append([]any{&category.Id, &category.Name, &category.Description, &category.Version}, fines.dest()...)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "category with ID %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to lock category: %w", errs.DB(err))
+	}
+	category.FinePolicy = fines.policy()
+
+	return &category, nil
+}
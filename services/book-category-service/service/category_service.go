@@ -3,14 +3,22 @@ package service
 import (
 	"context"
 	"fmt"
-	"strings"
+	"slices"
+	"sync"
 	"time"
 
 	api "github.com/daffaromero/gobook/protobuf/api"
 	"github.com/daffaromero/gobook/services/book-category-service/repository"
+	"github.com/daffaromero/gobook/services/common/audit"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/discovery"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/fieldmask"
 	"github.com/daffaromero/gobook/services/common/helper/logger"
-	"github.com/gofiber/fiber/v3"
+	"github.com/daffaromero/gobook/services/common/outbox"
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -20,18 +28,50 @@ type CategoryService interface {
 	CreateCategory(ctx context.Context, req *api.CreateCategoryRequest, name string, desc string) (*api.CreateCategoryResponse, error)
 	UpdateCategory(ctx context.Context, req *api.UpdateCategoryRequest, name string, desc string) (*api.UpdateCategoryResponse, error)
 	DeleteCategory(ctx context.Context, req *api.DeleteCategoryRequest) (*api.DeleteCategoryResponse, error)
+	ListDeletedCategories(ctx context.Context, req *api.ListDeletedCategoriesRequest) (*api.ListDeletedCategoriesResponse, error)
+	RestoreCategory(ctx context.Context, req *api.RestoreCategoryRequest) (*api.RestoreCategoryResponse, error)
+	PurgeCategories(ctx context.Context, retention time.Duration) (int64, error)
+	ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error)
+	WatchCategories(ctx context.Context, req *api.WatchCategoriesRequest, send func(*api.CategoryEvent) error) error
 }
 
 type categoryService struct {
-	repo   repository.CategoryRepository
-	logger *logger.Log
+	registry discovery.Registry
+	repo     repository.CategoryRepository
+	logger   *logger.Log
+
+	booksMu sync.Mutex
+	books   api.BookServiceClient
 }
 
-func NewCategoryService(repo repository.CategoryRepository, logger *logger.Log) CategoryService {
+func NewCategoryService(registry discovery.Registry, repo repository.CategoryRepository, logger *logger.Log) CategoryService {
 	return &categoryService{
-		repo:   repo,
-		logger: logger,
+		registry: registry,
+		repo:     repo,
+		logger:   logger,
+	}
+}
+
+// bookClient connects to book-service on first use. Book-service dials this
+// service at startup, so connecting eagerly here would make a cold start of
+// either one wait on the other.
+func (s *categoryService) bookClient(ctx context.Context) (api.BookServiceClient, error) {
+	s.booksMu.Lock()
+	defer s.booksMu.Unlock()
+
+	if s.books != nil {
+		return s.books, nil
+	}
+
+	conn, err := discovery.ServiceConnection(ctx, "book-service-grpc", s.registry)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to connect to book-service: %v", err))
+		return nil, errs.Wrap(errs.Unavailable, err, "Book Service is not available.")
 	}
+	s.logger.Log(fmt.Sprintf("Connected to book-service at %s", conn.Target()))
+
+	s.books = api.NewBookServiceClient(conn)
+	return s.books, nil
 }
 
 func (s *categoryService) GetCategory(ctx context.Context, req *api.GetCategoryRequest) (*api.GetCategoryResponse, error) {
@@ -44,6 +84,10 @@ func (s *categoryService) GetCategory(ctx context.Context, req *api.GetCategoryR
 }
 
 func (s *categoryService) ListCategories(ctx context.Context, req *api.ListCategoriesRequest) (*api.ListCategoriesResponse, error) {
+	if req == nil {
+		req = &api.ListCategoriesRequest{}
+	}
+
 	categories, err := s.repo.ListCategories(ctx, req)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("Failed to list categories: %v", err))
@@ -52,7 +96,17 @@ func (s *categoryService) ListCategories(ctx context.Context, req *api.ListCateg
 	return categories, nil
 }
 
+func validFinePolicy(p *api.FinePolicy) error {
+	if p != nil && (p.DailyRateCents < 0 || p.GraceDays < 0 || p.CapCents < 0) {
+		return errs.New(errs.InvalidArgument, "fine policy values cannot be negative")
+	}
+	return nil
+}
+
 func (s *categoryService) CreateCategory(ctx context.Context, req *api.CreateCategoryRequest, name string, desc string) (*api.CreateCategoryResponse, error) {
+	if err := validFinePolicy(req.Category.FinePolicy); err != nil {
+		return nil, err
+	}
 
 	now := &timestamppb.Timestamp{
 		Seconds: time.Now().Unix(),
@@ -67,8 +121,8 @@ func (s *categoryService) CreateCategory(ctx context.Context, req *api.CreateCat
 	res, err := s.repo.CreateCategory(ctx, req)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("Failed to create category: %v", err))
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-			return nil, fiber.NewError(fiber.StatusBadRequest, "Category already exists.")
+		if errs.Is(err, errs.AlreadyExists) {
+			return nil, errs.New(errs.AlreadyExists, "Category already exists.")
 		}
 		return nil, err
 	}
@@ -76,13 +130,57 @@ func (s *categoryService) CreateCategory(ctx context.Context, req *api.CreateCat
 	return res, nil
 }
 
+// categoryFields are the category fields an update mask may name. Masking
+// fine_policy without sending one resets the category to the default rules.
+var categoryFields = []string{"name", "description", "fine_policy"}
+
+// categoryMask resolves the fields an update writes. Without a mask, the
+// non-empty fields of category are written.
+func categoryMask(req *api.UpdateCategoryRequest) ([]string, error) {
+	if len(req.UpdateMask.GetPaths()) > 0 {
+		paths, err := fieldmask.Resolve(req.UpdateMask, categoryFields...)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(paths, "name") && req.Category.Name == "" {
+			return nil, errs.New(errs.InvalidArgument, "name cannot be empty")
+		}
+		return paths, nil
+	}
+
+	var paths []string
+	if req.Category.Name != "" {
+		paths = append(paths, "name")
+	}
+	if req.Category.Description != "" {
+		paths = append(paths, "description")
+	}
+	if req.Category.FinePolicy != nil {
+		paths = append(paths, "fine_policy")
+	}
+	return paths, nil
+}
+
 func (s *categoryService) UpdateCategory(ctx context.Context, req *api.UpdateCategoryRequest, name string, desc string) (*api.UpdateCategoryResponse, error) {
+	if err := validFinePolicy(req.Category.FinePolicy); err != nil {
+		return nil, err
+	}
+	req.Category.Name = name
+	req.Category.Description = desc
+
+	paths, err := categoryMask(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, errs.New(errs.InvalidArgument, "At least one field (name, description or fine_policy) must be provided for update")
+	}
+	req.UpdateMask = &fieldmaskpb.FieldMask{Paths: paths}
+
 	now := &timestamppb.Timestamp{
 		Seconds: time.Now().Unix(),
 		Nanos:   int32(time.Now().Nanosecond()),
 	}
-	req.Category.Name = name
-	req.Category.Description = desc
 	req.Category.UpdatedAt = now
 
 	res, err := s.repo.UpdateCategory(ctx, req)
@@ -94,12 +192,150 @@ func (s *categoryService) UpdateCategory(ctx context.Context, req *api.UpdateCat
 	return res, nil
 }
 
+// DeleteCategory soft-deletes a category and deals with the books that still
+// reference it, according to req.Mode: refuse while any remain, move them to
+// req.FallbackCategoryId, or soft-delete them along with the category.
+//
+// Book-service closes the category first: in one transaction it deals with
+// the books and stops accepting new ones, so none can be left pointing at
+// the deleted category. The category is then deleted. If that fails, because
+// it changed after its version was checked here or the database could not
+// be reached, book-service reopens it, but the books it moved or deleted are
+// not put back.
 func (s *categoryService) DeleteCategory(ctx context.Context, req *api.DeleteCategoryRequest) (*api.DeleteCategoryResponse, error) {
+	current, err := s.repo.GetCategory(ctx, &api.GetCategoryRequest{CategoryId: req.CategoryId})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to get category: %v", err))
+		return nil, err
+	}
+	// Check the version before calling book-service; the delete itself checks
+	// it again.
+	if current.Category.Version != req.ExpectedVersion {
+		return nil, errs.Newf(errs.FailedPrecondition, "category with ID %s has been modified: version is %d, not %d", req.CategoryId, current.Category.Version, req.ExpectedVersion)
+	}
+	if req.Mode == api.DeleteCategoryMode_DELETE_CATEGORY_MODE_REASSIGN && (req.FallbackCategoryId == "" || req.FallbackCategoryId == req.CategoryId) {
+		return nil, errs.New(errs.InvalidArgument, "a different fallback category is required to reassign books")
+	}
+
+	books, err := s.bookClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Book-service authorizes the calls as the user deleting the category,
+	// and audits them under the same request.
+	outCtx := audit.ForwardRequestID(auth.ForwardToken(ctx))
+
+	closed, err := books.CloseCategory(outCtx, &api.CloseCategoryRequest{
+		CategoryId:         req.CategoryId,
+		Mode:               req.Mode,
+		FallbackCategoryId: req.FallbackCategoryId,
+	})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("(RPC) Failed to close category to books: %v", err))
+		return nil, errs.FromStatus(err)
+	}
+
 	res, err := s.repo.DeleteCategory(ctx, req)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("Failed to delete category: %v", err))
+		// The category stays, so books may be put in it again. This runs
+		// even if ctx is cancelled, since the failure may be the
+		// cancellation.
+		if _, reopenErr := books.ReopenCategory(context.WithoutCancel(outCtx), &api.ReopenCategoryRequest{CategoryId: req.CategoryId}); reopenErr != nil {
+			s.logger.Error(fmt.Sprintf("(RPC) Failed to reopen category %s to books after an unfinished delete: %v", req.CategoryId, reopenErr))
+		}
 		return nil, err
 	}
+	res.AffectedBooks = closed.AffectedBooks
+
+	return res, nil
+}
+
+func (s *categoryService) ListDeletedCategories(ctx context.Context, req *api.ListDeletedCategoriesRequest) (*api.ListDeletedCategoriesResponse, error) {
+	if req == nil {
+		req = &api.ListDeletedCategoriesRequest{}
+	}
+
+	categories, err := s.repo.ListDeletedCategories(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list deleted categories: %v", err))
+		return nil, err
+	}
+	return categories, nil
+}
+
+// RestoreCategory takes a category out of the trash. Book-service is asked
+// first to accept books in it again, so a failure there leaves the category
+// deleted and the restore can simply be retried.
+func (s *categoryService) RestoreCategory(ctx context.Context, req *api.RestoreCategoryRequest) (*api.RestoreCategoryResponse, error) {
+	books, err := s.bookClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = books.ReopenCategory(audit.ForwardRequestID(auth.ForwardToken(ctx)), &api.ReopenCategoryRequest{CategoryId: req.CategoryId})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("(RPC) Failed to reopen category to books: %v", err))
+		return nil, errs.FromStatus(err)
+	}
 
+	res, err := s.repo.RestoreCategory(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to restore category: %v", err))
+		return nil, err
+	}
 	return res, nil
 }
+
+// PurgeCategories permanently removes categories that have been in the trash
+// for longer than retention.
+func (s *categoryService) PurgeCategories(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.repo.PurgeCategories(ctx, time.Now().Add(-retention))
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to purge categories: %v", err))
+		return 0, err
+	}
+
+	if purged > 0 {
+		s.logger.Log(fmt.Sprintf("Purged %d categories deleted more than %s ago", purged, retention))
+	}
+	return purged, nil
+}
+
+func (s *categoryService) ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error) {
+	if req == nil {
+		req = &api.ListAuditEntriesRequest{}
+	}
+	if req.Since != nil && req.Until != nil && !req.Since.AsTime().Before(req.Until.AsTime()) {
+		return nil, errs.New(errs.InvalidArgument, "since must be before until")
+	}
+
+	entries, err := s.repo.ListAuditEntries(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list audit entries: %v", err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WatchCategories sends each change after req.AfterSequence to send, waiting for
+// send to return before reading on, until ctx is cancelled or send fails.
+func (s *categoryService) WatchCategories(ctx context.Context, req *api.WatchCategoriesRequest, send func(*api.CategoryEvent) error) error {
+	err := s.repo.WatchCategories(ctx, req.GetAfterSequence(), func(e outbox.Event) error {
+		var category api.BookCategory
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(e.Payload, &category); err != nil {
+			return fmt.Errorf("failed to decode %s event %s: %w", e.Topic, e.ID, err)
+		}
+
+		return send(&api.CategoryEvent{
+			Sequence:  e.Sequence,
+			Type:      e.ChangeType(),
+			Category:  &category,
+			RequestId: e.RequestID,
+			CreatedAt: timestamppb.New(e.CreatedAt),
+		})
+	})
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error(fmt.Sprintf("Failed to watch categories: %v", err))
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/config"
+	"github.com/daffaromero/gobook/services/book-service/controller"
+	"github.com/daffaromero/gobook/services/book-service/migrations"
+	"github.com/daffaromero/gobook/services/book-service/repository"
+	"github.com/daffaromero/gobook/services/book-service/repository/query"
+	"github.com/daffaromero/gobook/services/book-service/service"
+	"github.com/daffaromero/gobook/services/common/audit"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/outbox"
+	"github.com/daffaromero/gobook/services/common/servicetest"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// anyCategory finds every category, so books can be written without
+// book-category-service.
+type anyCategory struct{}
+
+func (anyCategory) GetCategory(ctx context.Context, id string) (*api.BookCategory, error) {
+	return &api.BookCategory{Id: id, Name: "Test"}, nil
+}
+
+// TestBookWrites serves books from the database named by
+// TEST_BOOKS_DATABASE_URL and writes them over HTTP and gRPC.
+func TestBookWrites(t *testing.T) {
+	pool := servicetest.OpenDB(t, "TEST_BOOKS_DATABASE_URL", migrations.FS)
+	if config.TimeOutDuration <= 0 {
+		config.TimeOutDuration = 10
+	}
+
+	repo := repository.NewBookRepository(repository.NewStore(pool), query.NewBookQuery(pool), audit.NewQuery(pool), outbox.NewFeed(pool, logs))
+	bookService := service.NewBookService(anyCategory{}, repo, logs)
+
+	tokens, token := servicetest.Token(t, auth.RoleLibrarian)
+	policy := auth.NewPolicy(config.AccessRules)
+
+	app := fiber.New()
+	controller.NewBookController(validator.New(), bookService, auth.Middleware(tokens), policy).Route(app)
+
+	server := newGRPCServer(tokens, policy)
+	NewBookGRPCHandler(server, bookService, nil)
+	client := api.NewBookServiceClient(servicetest.Dial(t, server))
+	ctx := servicetest.WithToken(token)
+
+	writers := []servicetest.Writer{
+		servicetest.HTTPWriter(app, config.EndpointPrefix, token, func() string {
+			return `{"book":{"title":"Renamed"}}`
+		}),
+		{
+			Name: "gRPC",
+			Update: func(id string, version int64) string {
+				_, err := client.UpdateBook(ctx, &api.UpdateBookRequest{
+					Book:            &api.Book{Id: id, Title: "Renamed"},
+					UpdateMask:      &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+					ExpectedVersion: version,
+				})
+				return servicetest.GRPCOutcome(err)
+			},
+			Delete: func(id string, version int64) string {
+				_, err := client.DeleteBook(ctx, &api.DeleteBookRequest{BookId: id, ExpectedVersion: version})
+				return servicetest.GRPCOutcome(err)
+			},
+		},
+	}
+
+	servicetest.TestWrites(t, writers, func(t *testing.T) (string, int64) {
+		t.Helper()
+
+		res, err := bookService.CreateBook(context.Background(), &api.CreateBookRequest{Book: &api.Book{}}, "Title "+uuid.New().String(), "Author", uuid.New().String(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Book.Id, res.Book.Version
+	})
+}
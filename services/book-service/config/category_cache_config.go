@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+var (
+	// Categories are looked up again once cached this long.
+	CategoryCacheTTL         = time.Duration(intEnv("CATEGORY_CACHE_TTL_SECONDS", 300)) * time.Second
+	CategoryCacheNegativeTTL = time.Duration(intEnv("CATEGORY_CACHE_NEGATIVE_TTL_SECONDS", 30)) * time.Second
+	// How long past the TTL a cached category is still used while it is
+	// refreshed in the background.
+	CategoryCacheMaxStale   = time.Duration(intEnv("CATEGORY_CACHE_MAX_STALE_SECONDS", 3600)) * time.Second
+	CategoryCacheMaxEntries = intEnv("CATEGORY_CACHE_MAX_ENTRIES", 10000)
+)
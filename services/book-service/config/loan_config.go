@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/utils"
+)
+
+var (
+	LoanPeriodDays  = intEnv("LOAN_PERIOD_DAYS", 14)
+	LoanMaxRenewals = intEnv("LOAN_MAX_RENEWALS", 2)
+	LoanMaxActive   = intEnv("LOAN_MAX_ACTIVE", 5)
+
+	HoldPickupDays     = intEnv("HOLD_PICKUP_DAYS", 3)
+	HoldExpiryInterval = time.Duration(intEnv("HOLD_EXPIRY_INTERVAL_MINUTES", 15)) * time.Minute
+
+	// Defaults for categories without their own fine policy.
+	FineDailyRateCents  = int64(intEnv("FINE_DAILY_RATE_CENTS", 25))
+	FineGraceDays       = int32(intEnv("FINE_GRACE_DAYS", 1))
+	FineCapCents        = int64(intEnv("FINE_CAP_CENTS", 1000))
+	FineBlockCents      = int64(intEnv("FINE_BLOCK_CENTS", 500))
+	FineAccrualInterval = time.Duration(intEnv("FINE_ACCRUAL_INTERVAL_MINUTES", 60)) * time.Minute
+)
+
+func intEnv(key string, fallback int) int {
+	n, err := strconv.Atoi(utils.GetEnv(key))
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package config
+
+import "github.com/daffaromero/gobook/services/common/auth"
+
+var adminOnly = []string{auth.RoleAdmin}
+
+// AccessRules lists the roles allowed to perform each action. RPC method names
+// apply to gRPC calls and to the matching HTTP routes; ViewMetrics and
+// ManageLoans guard HTTP routes only.
+var AccessRules = map[string][]string{
+	"CreateBook": auth.CatalogWriters,
+	"UpdateBook": auth.CatalogWriters,
+	"DeleteBook": auth.CatalogWriters,
+
+	"ListDeletedBooks": adminOnly,
+	"RestoreBook":      adminOnly,
+	"ListAuditEntries": adminOnly,
+	"ViewMetrics":      adminOnly,
+
+	// Called by book-category-service on behalf of whoever deletes a category.
+	"ReassignCategory":      auth.CatalogWriters,
+	"DeleteBooksByCategory": auth.CatalogWriters,
+	"CloseCategory":         auth.CatalogWriters,
+	"ReopenCategory":        auth.CatalogWriters,
+
+	"AddCopy":      auth.CatalogWriters,
+	"RetireCopy":   auth.CatalogWriters,
+	"RelocateCopy": auth.CatalogWriters,
+
+	// Members use the HTTP routes for their own loans and holds; acting
+	// for someone else, and every LoanService RPC, is staff only.
+	"ManageLoans":  auth.CatalogWriters,
+	"CheckoutCopy": auth.CatalogWriters,
+	"ReturnCopy":   auth.CatalogWriters,
+	"RenewLoan":    auth.CatalogWriters,
+	"ListLoans":    auth.CatalogWriters,
+	"PlaceHold":    auth.CatalogWriters,
+	"CancelHold":   auth.CatalogWriters,
+	"GetHold":      auth.CatalogWriters,
+	"ListHolds":    auth.CatalogWriters,
+
+	"GetBalance":    auth.CatalogWriters,
+	"ListLedger":    auth.CatalogWriters,
+	"WaiveFine":     auth.CatalogWriters,
+	"RecordPayment": auth.CatalogWriters,
+}
@@ -13,6 +13,9 @@ type ServerConfig struct {
 	HTTP       string
 	HTTPAddr   string
 	HTTPPort   string
+	GRPC       string
+	GRPCAddr   string
+	GRPCPort   string
 	ConsulAddr string
 	Name       string
 }
@@ -26,6 +29,14 @@ func NewServerConfig() ServerConfig {
 	if port == "" {
 		log.Fatal("HTTP_PORT environment variable is not set")
 	}
+	grpcAddr := utils.GetEnv("GRPC_ADDR")
+	if grpcAddr == "" {
+		log.Fatal("GRPC_ADDR environment variable is not set")
+	}
+	grpcPort := utils.GetEnv("GRPC_PORT")
+	if grpcPort == "" {
+		log.Fatal("GRPC_PORT environment variable is not set")
+	}
 	consulAddr := utils.GetEnv("CONSUL_ADDR")
 	if consulAddr == "" {
 		log.Fatal("CONSUL_ADDR environment variable is not set")
@@ -38,6 +49,9 @@ func NewServerConfig() ServerConfig {
 		HTTP:       fmt.Sprintf("%s:%s", httpAddr, port),
 		HTTPAddr:   httpAddr,
 		HTTPPort:   port,
+		GRPC:       fmt.Sprintf("%s:%s", grpcAddr, grpcPort),
+		GRPCAddr:   grpcAddr,
+		GRPCPort:   grpcPort,
 		ConsulAddr: consulAddr,
 		Name:       name,
 	}
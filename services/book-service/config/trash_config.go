@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+var (
+	// Deleted books are purged once they have been in the trash this long.
+	TrashRetention     = time.Duration(intEnv("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour
+	TrashPurgeInterval = time.Duration(intEnv("TRASH_PURGE_INTERVAL_MINUTES", 60)) * time.Minute
+)
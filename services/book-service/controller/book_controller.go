@@ -4,6 +4,12 @@ import (
 	api "github.com/daffaromero/gobook/protobuf/api"
 	"github.com/daffaromero/gobook/services/book-service/config"
 	"github.com/daffaromero/gobook/services/book-service/service"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/etag"
+	"github.com/daffaromero/gobook/services/common/fieldmask"
+	"github.com/daffaromero/gobook/services/common/pagination"
+	"github.com/daffaromero/gobook/services/common/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
 )
@@ -12,30 +18,44 @@ type BookController interface {
 	Route(*fiber.App)
 	GetBook(ctx fiber.Ctx) error
 	ListBooks(ctx fiber.Ctx) error
+	SearchBooks(ctx fiber.Ctx) error
 	CreateBook(ctx fiber.Ctx) error
 	UpdateBook(ctx fiber.Ctx) error
+	PatchBook(ctx fiber.Ctx) error
 	DeleteBook(ctx fiber.Ctx) error
+	ListDeletedBooks(ctx fiber.Ctx) error
+	RestoreBook(ctx fiber.Ctx) error
+	ListAuditEntries(ctx fiber.Ctx) error
 }
 
 type bookController struct {
-	validate *validator.Validate
-	service  service.BookService
+	validate     *validator.Validate
+	service      service.BookService
+	authenticate fiber.Handler
+	policy       *auth.Policy
 }
 
-func NewBookController(validate *validator.Validate, service service.BookService) BookController {
+func NewBookController(validate *validator.Validate, service service.BookService, authenticate fiber.Handler, policy *auth.Policy) BookController {
 	return &bookController{
-		validate: validate,
-		service:  service,
+		validate:     validate,
+		service:      service,
+		authenticate: authenticate,
+		policy:       policy,
 	}
 }
 
 func (c *bookController) Route(app *fiber.App) {
 	api := app.Group(config.EndpointPrefix)
+	api.Get("/search", c.SearchBooks)
+	api.Get("/trash", c.ListDeletedBooks, c.authenticate, c.policy.Require("ListDeletedBooks"))
+	api.Post("/trash/:id/restore", c.RestoreBook, c.authenticate, c.policy.Require("RestoreBook"))
+	api.Get("/audit", c.ListAuditEntries, c.authenticate, c.policy.Require("ListAuditEntries"))
 	api.Get("/:id", c.GetBook)
 	api.Get("/", c.ListBooks)
-	api.Post("/new", c.CreateBook)
-	api.Put("/:id", c.UpdateBook)
-	api.Delete("/:id", c.DeleteBook)
+	api.Post("/new", c.CreateBook, c.authenticate, c.policy.Require("CreateBook"))
+	api.Put("/:id", c.UpdateBook, c.authenticate, c.policy.Require("UpdateBook"), etag.RequireIfMatch)
+	api.Patch("/:id", c.PatchBook, c.authenticate, c.policy.Require("UpdateBook"), etag.RequireIfMatch)
+	api.Delete("/:id", c.DeleteBook, c.authenticate, c.policy.Require("DeleteBook"), etag.RequireIfMatch)
 }
 
 func (c *bookController) GetBook(ctx fiber.Ctx) error {
@@ -47,18 +67,51 @@ func (c *bookController) GetBook(ctx fiber.Ctx) error {
 
 	res, err := c.service.GetBook(ctx.Context(), &req)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
 	}
+	etag.Set(ctx, res.Book.GetVersion())
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
 }
 
 func (c *bookController) ListBooks(ctx fiber.Ctx) error {
-	var req *api.ListBooksRequest
+	page, sorting, search, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &api.ListBooksRequest{
+		Pagination: page,
+		Sorting:    sorting,
+		Search:     search,
+		PageToken:  ctx.Query("page_token"),
+	}
 
 	res, err := c.service.ListBooks(ctx.Context(), req)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *bookController) SearchBooks(ctx fiber.Ctx) error {
+	page, _, _, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &api.SearchBooksRequest{
+		Query:      ctx.Query("q"),
+		Pagination: page,
+	}
+	if req.Query == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "q not provided"})
+	}
+
+	res, err := c.service.SearchBooks(ctx.Context(), req)
+	if err != nil {
+		return errs.Respond(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
@@ -80,8 +133,9 @@ func (c *bookController) CreateBook(ctx fiber.Ctx) error {
 
 	res, err := c.service.CreateBook(ctx.Context(), &req, req.Book.Title, req.Book.Author, req.Book.CategoryId, req.Book.Description)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
 	}
+	etag.Set(ctx, res.Book.GetVersion())
 
 	return ctx.Status(fiber.StatusCreated).JSON(res)
 }
@@ -110,14 +164,50 @@ func (c *bookController) UpdateBook(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	if req.Book.Author == "" && req.Book.CategoryId == "" && req.Book.Description == "" && req.Book.Title == "" {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "no fields to update"})
+	req.ExpectedVersion = etag.Version(ctx)
+
+	res, err := c.service.UpdateBook(ctx.Context(), &req, req.Book.Title, req.Book.Author, req.Book.CategoryId, req.Book.Description)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+	etag.Set(ctx, res.Book.GetVersion())
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// PatchBook writes only the fields present in the request body, unless the
+// body carries its own update_mask.
+func (c *bookController) PatchBook(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "book_id not provided"})
+	}
+
+	var req api.UpdateBookRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.Book == nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "book cannot be empty"})
+	}
+	req.Book.Id = id
+
+	if req.UpdateMask == nil {
+		mask, err := fieldmask.FromBody(ctx.Body(), "book")
+		if err != nil {
+			return errs.Respond(ctx, err)
+		}
+		req.UpdateMask = mask
 	}
 
+	req.ExpectedVersion = etag.Version(ctx)
+
 	res, err := c.service.UpdateBook(ctx.Context(), &req, req.Book.Title, req.Book.Author, req.Book.CategoryId, req.Book.Description)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
 	}
+	etag.Set(ctx, res.Book.GetVersion())
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
 }
@@ -128,10 +218,74 @@ func (c *bookController) DeleteBook(ctx fiber.Ctx) error {
 	if req.BookId == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "book_id not provided"})
 	}
+	req.ExpectedVersion = etag.Version(ctx)
 
 	res, err := c.service.DeleteBook(ctx.Context(), &req)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *bookController) ListDeletedBooks(ctx fiber.Ctx) error {
+	page, _, _, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := c.service.ListDeletedBooks(ctx.Context(), &api.ListDeletedBooksRequest{Pagination: page})
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *bookController) RestoreBook(ctx fiber.Ctx) error {
+	var req api.RestoreBookRequest
+	req.BookId = ctx.Params("id")
+	if req.BookId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "book_id not provided"})
+	}
+
+	res, err := c.service.RestoreBook(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+	etag.Set(ctx, res.Book.GetVersion())
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// ListAuditEntries filters the audit log by entity_id, actor_id and a
+// since/until range of RFC 3339 timestamps.
+func (c *bookController) ListAuditEntries(ctx fiber.Ctx) error {
+	page, _, _, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	since, err := utils.TimeQuery(ctx, "since")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	until, err := utils.TimeQuery(ctx, "until")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &api.ListAuditEntriesRequest{
+		EntityId:   ctx.Query("entity_id"),
+		ActorId:    ctx.Query("actor_id"),
+		Since:      since,
+		Until:      until,
+		Pagination: page,
+	}
+
+	res, err := c.service.ListAuditEntries(ctx.Context(), req)
+	if err != nil {
+		return errs.Respond(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/config"
+	"github.com/daffaromero/gobook/services/book-service/service"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+)
+
+type CopyController interface {
+	Route(*fiber.App)
+	ListCopies(ctx fiber.Ctx) error
+	AddCopy(ctx fiber.Ctx) error
+	RetireCopy(ctx fiber.Ctx) error
+	RelocateCopy(ctx fiber.Ctx) error
+}
+
+type copyController struct {
+	validate     *validator.Validate
+	service      service.CopyService
+	authenticate fiber.Handler
+	policy       *auth.Policy
+}
+
+func NewCopyController(validate *validator.Validate, service service.CopyService, authenticate fiber.Handler, policy *auth.Policy) CopyController {
+	return &copyController{
+		validate:     validate,
+		service:      service,
+		authenticate: authenticate,
+		policy:       policy,
+	}
+}
+
+func (c *copyController) Route(app *fiber.App) {
+	api := app.Group(config.EndpointPrefix)
+	api.Get("/:id/copies", c.ListCopies)
+	api.Post("/:id/copies", c.AddCopy, c.authenticate, c.policy.Require("AddCopy"))
+	api.Post("/copies/:copyId/retire", c.RetireCopy, c.authenticate, c.policy.Require("RetireCopy"))
+	api.Put("/copies/:copyId/location", c.RelocateCopy, c.authenticate, c.policy.Require("RelocateCopy"))
+}
+
+func (c *copyController) ListCopies(ctx fiber.Ctx) error {
+	var req api.ListCopiesRequest
+	req.BookId = ctx.Params("id")
+	if req.BookId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "book_id not provided"})
+	}
+	req.IncludeRetired = fiber.Query[bool](ctx, "include_retired")
+
+	res, err := c.service.ListCopies(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *copyController) AddCopy(ctx fiber.Ctx) error {
+	var bookCopy api.BookCopy
+	if err := ctx.Bind().Body(&bookCopy); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	bookCopy.BookId = ctx.Params("id")
+	if bookCopy.BookId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "book_id not provided"})
+	}
+	if bookCopy.Barcode == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "barcode not provided"})
+	}
+
+	res, err := c.service.AddCopy(ctx.Context(), &api.AddCopyRequest{Copy: &bookCopy})
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (c *copyController) RetireCopy(ctx fiber.Ctx) error {
+	var req api.RetireCopyRequest
+	req.CopyId = ctx.Params("copyId")
+	if req.CopyId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "copy_id not provided"})
+	}
+
+	res, err := c.service.RetireCopy(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *copyController) RelocateCopy(ctx fiber.Ctx) error {
+	var req api.RelocateCopyRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req.CopyId = ctx.Params("copyId")
+	if req.CopyId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "copy_id not provided"})
+	}
+	if req.Location == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "location not provided"})
+	}
+
+	res, err := c.service.RelocateCopy(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/config"
+	"github.com/daffaromero/gobook/services/book-service/service"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/pagination"
+	"github.com/gofiber/fiber/v3"
+)
+
+type FineController interface {
+	Route(*fiber.App)
+	GetBalance(ctx fiber.Ctx) error
+	ListLedger(ctx fiber.Ctx) error
+	WaiveFine(ctx fiber.Ctx) error
+	RecordPayment(ctx fiber.Ctx) error
+}
+
+type fineController struct {
+	service      service.FineService
+	authenticate fiber.Handler
+	policy       *auth.Policy
+}
+
+func NewFineController(service service.FineService, authenticate fiber.Handler, policy *auth.Policy) FineController {
+	return &fineController{
+		service:      service,
+		authenticate: authenticate,
+		policy:       policy,
+	}
+}
+
+func (c *fineController) Route(app *fiber.App) {
+	api := app.Group(config.EndpointPrefix)
+	api.Get("/fines/balance", c.GetBalance, c.authenticate)
+	api.Get("/fines/ledger", c.ListLedger, c.authenticate)
+	api.Post("/fines/waivers", c.WaiveFine, c.authenticate, c.policy.Require("WaiveFine"))
+	api.Post("/fines/payments", c.RecordPayment, c.authenticate, c.policy.Require("RecordPayment"))
+}
+
+// member resolves the user_id query parameter, defaulting to the caller.
+// Looking at someone else's account requires the ManageLoans rule.
+func (c *fineController) member(ctx fiber.Ctx) (string, error) {
+	userID := ctx.Query("user_id")
+	if userID == "" || userID == auth.UserID(ctx) {
+		return auth.UserID(ctx), nil
+	}
+	if err := c.policy.Authorize(ctx.Context(), "ManageLoans"); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func (c *fineController) GetBalance(ctx fiber.Ctx) error {
+	userID, err := c.member(ctx)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	res, err := c.service.GetBalance(ctx.Context(), &api.GetBalanceRequest{UserId: userID})
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *fineController) ListLedger(ctx fiber.Ctx) error {
+	userID, err := c.member(ctx)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	page, _, _, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := c.service.ListLedger(ctx.Context(), &api.ListLedgerRequest{
+		UserId:     userID,
+		Pagination: page,
+	})
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *fineController) WaiveFine(ctx fiber.Ctx) error {
+	var req api.WaiveFineRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.UserId == "" || req.AmountCents <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id and a positive amount_cents are required"})
+	}
+
+	res, err := c.service.WaiveFine(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (c *fineController) RecordPayment(ctx fiber.Ctx) error {
+	var req api.RecordPaymentRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.UserId == "" || req.AmountCents <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id and a positive amount_cents are required"})
+	}
+
+	res, err := c.service.RecordPayment(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
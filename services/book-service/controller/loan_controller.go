@@ -0,0 +1,261 @@
+package controller
+
+import (
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/config"
+	"github.com/daffaromero/gobook/services/book-service/service"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/pagination"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+)
+
+type LoanController interface {
+	Route(*fiber.App)
+	CheckoutCopy(ctx fiber.Ctx) error
+	ReturnCopy(ctx fiber.Ctx) error
+	RenewLoan(ctx fiber.Ctx) error
+	ListLoans(ctx fiber.Ctx) error
+	ListBookLoans(ctx fiber.Ctx) error
+	PlaceHold(ctx fiber.Ctx) error
+	CancelHold(ctx fiber.Ctx) error
+	GetHold(ctx fiber.Ctx) error
+	ListHolds(ctx fiber.Ctx) error
+}
+
+type loanController struct {
+	validate     *validator.Validate
+	service      service.LoanService
+	authenticate fiber.Handler
+	policy       *auth.Policy
+}
+
+func NewLoanController(validate *validator.Validate, service service.LoanService, authenticate fiber.Handler, policy *auth.Policy) LoanController {
+	return &loanController{
+		validate:     validate,
+		service:      service,
+		authenticate: authenticate,
+		policy:       policy,
+	}
+}
+
+func (c *loanController) Route(app *fiber.App) {
+	api := app.Group(config.EndpointPrefix)
+	api.Get("/loans", c.ListLoans, c.authenticate)
+	api.Post("/loans", c.CheckoutCopy, c.authenticate)
+	api.Post("/loans/return", c.ReturnCopy, c.authenticate, c.policy.Require("ReturnCopy"))
+	api.Post("/loans/:loanId/renew", c.RenewLoan, c.authenticate)
+	api.Get("/:id/loans", c.ListBookLoans, c.authenticate, c.policy.Require("ManageLoans"))
+
+	api.Get("/holds", c.ListHolds, c.authenticate)
+	api.Get("/holds/:holdId", c.GetHold, c.authenticate)
+	api.Delete("/holds/:holdId", c.CancelHold, c.authenticate)
+	api.Post("/:id/holds", c.PlaceHold, c.authenticate)
+}
+
+// isStaff reports whether the caller may act on other users' loans.
+func (c *loanController) isStaff(ctx fiber.Ctx) bool {
+	return c.policy.Authorize(ctx.Context(), "ManageLoans") == nil
+}
+
+func (c *loanController) CheckoutCopy(ctx fiber.Ctx) error {
+	var req api.CheckoutCopyRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.CopyId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "copy_id not provided"})
+	}
+	if req.UserId == "" {
+		req.UserId = auth.UserID(ctx)
+	}
+	if req.UserId != auth.UserID(ctx) && !c.isStaff(ctx) {
+		return errs.Respond(ctx, auth.ErrPermissionDenied)
+	}
+
+	res, err := c.service.CheckoutCopy(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (c *loanController) ReturnCopy(ctx fiber.Ctx) error {
+	var req api.ReturnCopyRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.CopyId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "copy_id not provided"})
+	}
+
+	res, err := c.service.ReturnCopy(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *loanController) RenewLoan(ctx fiber.Ctx) error {
+	var req api.RenewLoanRequest
+	req.LoanId = ctx.Params("loanId")
+	if req.LoanId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "loan_id not provided"})
+	}
+
+	// Borrowers may only renew their own loans.
+	if !c.isStaff(ctx) {
+		req.UserId = auth.UserID(ctx)
+	}
+
+	res, err := c.service.RenewLoan(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *loanController) ListLoans(ctx fiber.Ctx) error {
+	page, _, _, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &api.ListLoansRequest{
+		UserId:     ctx.Query("user_id"),
+		BookId:     ctx.Query("book_id"),
+		ActiveOnly: fiber.Query[bool](ctx, "active"),
+		Pagination: page,
+	}
+
+	if !c.isStaff(ctx) {
+		if req.UserId != "" && req.UserId != auth.UserID(ctx) {
+			return errs.Respond(ctx, auth.ErrPermissionDenied)
+		}
+		req.UserId = auth.UserID(ctx)
+	}
+
+	res, err := c.service.ListLoans(ctx.Context(), req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *loanController) ListBookLoans(ctx fiber.Ctx) error {
+	page, _, _, err := pagination.FromQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &api.ListLoansRequest{
+		BookId:     ctx.Params("id"),
+		ActiveOnly: fiber.Query[bool](ctx, "active"),
+		Pagination: page,
+	}
+	if req.BookId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "book_id not provided"})
+	}
+
+	res, err := c.service.ListLoans(ctx.Context(), req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *loanController) PlaceHold(ctx fiber.Ctx) error {
+	var req api.PlaceHoldRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.Bind().Body(&req); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	req.BookId = ctx.Params("id")
+	if req.BookId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "book_id not provided"})
+	}
+	if req.UserId == "" {
+		req.UserId = auth.UserID(ctx)
+	}
+	if req.UserId != auth.UserID(ctx) && !c.isStaff(ctx) {
+		return errs.Respond(ctx, auth.ErrPermissionDenied)
+	}
+
+	res, err := c.service.PlaceHold(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (c *loanController) CancelHold(ctx fiber.Ctx) error {
+	var req api.CancelHoldRequest
+	req.HoldId = ctx.Params("holdId")
+	if req.HoldId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "hold_id not provided"})
+	}
+
+	// Members may only cancel their own holds.
+	if !c.isStaff(ctx) {
+		req.UserId = auth.UserID(ctx)
+	}
+
+	res, err := c.service.CancelHold(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *loanController) GetHold(ctx fiber.Ctx) error {
+	var req api.GetHoldRequest
+	req.HoldId = ctx.Params("holdId")
+	if req.HoldId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "hold_id not provided"})
+	}
+
+	if !c.isStaff(ctx) {
+		req.UserId = auth.UserID(ctx)
+	}
+
+	res, err := c.service.GetHold(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *loanController) ListHolds(ctx fiber.Ctx) error {
+	req := &api.ListHoldsRequest{
+		UserId:     ctx.Query("user_id"),
+		BookId:     ctx.Query("book_id"),
+		ActiveOnly: fiber.Query[bool](ctx, "active"),
+	}
+
+	if !c.isStaff(ctx) {
+		if req.UserId != "" && req.UserId != auth.UserID(ctx) {
+			return errs.Respond(ctx, auth.ErrPermissionDenied)
+		}
+		req.UserId = auth.UserID(ctx)
+	}
+
+	res, err := c.service.ListHolds(ctx.Context(), req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
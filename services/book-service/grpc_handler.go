@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type BookGRPCHandler struct {
+	api.UnimplementedBookServiceServer
+
+	service     service.BookService
+	copyService service.CopyService
+}
+
+func NewBookGRPCHandler(server *grpc.Server, service service.BookService, copyService service.CopyService) {
+	handler := &BookGRPCHandler{
+		service:     service,
+		copyService: copyService,
+	}
+
+	api.RegisterBookServiceServer(server, handler)
+}
+
+func (h *BookGRPCHandler) GetBook(ctx context.Context, req *api.GetBookRequest) (*api.GetBookResponse, error) {
+	if req.BookId == "" {
+		return nil, status.Error(codes.InvalidArgument, "book_id not provided")
+	}
+
+	res, err := h.service.GetBook(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) ListBooks(ctx context.Context, req *api.ListBooksRequest) (*api.ListBooksResponse, error) {
+	res, err := h.service.ListBooks(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) SearchBooks(ctx context.Context, req *api.SearchBooksRequest) (*api.SearchBooksResponse, error) {
+	if req.Query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query not provided")
+	}
+
+	res, err := h.service.SearchBooks(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) CreateBook(ctx context.Context, req *api.CreateBookRequest) (*api.CreateBookResponse, error) {
+	if req.Book == nil {
+		return nil, status.Error(codes.InvalidArgument, "book cannot be empty")
+	}
+
+	res, err := h.service.CreateBook(ctx, req, req.Book.Title, req.Book.Author, req.Book.CategoryId, req.Book.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) UpdateBook(ctx context.Context, req *api.UpdateBookRequest) (*api.UpdateBookResponse, error) {
+	if req.Book == nil || req.Book.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "book and book id cannot be empty")
+	}
+	if req.ExpectedVersion <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version not provided")
+	}
+
+	res, err := h.service.UpdateBook(ctx, req, req.Book.Title, req.Book.Author, req.Book.CategoryId, req.Book.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) DeleteBook(ctx context.Context, req *api.DeleteBookRequest) (*api.DeleteBookResponse, error) {
+	if req.BookId == "" {
+		return nil, status.Error(codes.InvalidArgument, "book_id not provided")
+	}
+	if req.ExpectedVersion <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version not provided")
+	}
+
+	res, err := h.service.DeleteBook(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) ListDeletedBooks(ctx context.Context, req *api.ListDeletedBooksRequest) (*api.ListDeletedBooksResponse, error) {
+	return h.service.ListDeletedBooks(ctx, req)
+}
+
+func (h *BookGRPCHandler) RestoreBook(ctx context.Context, req *api.RestoreBookRequest) (*api.RestoreBookResponse, error) {
+	if req.BookId == "" {
+		return nil, status.Error(codes.InvalidArgument, "book_id not provided")
+	}
+
+	return h.service.RestoreBook(ctx, req)
+}
+
+func (h *BookGRPCHandler) CountBooksByCategory(ctx context.Context, req *api.CountBooksByCategoryRequest) (*api.CountBooksByCategoryResponse, error) {
+	if req.CategoryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "category_id not provided")
+	}
+
+	return h.service.CountBooksByCategory(ctx, req)
+}
+
+func (h *BookGRPCHandler) ReassignCategory(ctx context.Context, req *api.ReassignCategoryRequest) (*api.ReassignCategoryResponse, error) {
+	if req.FromCategoryId == "" || req.ToCategoryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "from_category_id and to_category_id cannot be empty")
+	}
+
+	return h.service.ReassignCategory(ctx, req)
+}
+
+func (h *BookGRPCHandler) DeleteBooksByCategory(ctx context.Context, req *api.DeleteBooksByCategoryRequest) (*api.DeleteBooksByCategoryResponse, error) {
+	if req.CategoryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "category_id not provided")
+	}
+
+	return h.service.DeleteBooksByCategory(ctx, req)
+}
+
+func (h *BookGRPCHandler) CloseCategory(ctx context.Context, req *api.CloseCategoryRequest) (*api.CloseCategoryResponse, error) {
+	if req.CategoryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "category_id not provided")
+	}
+
+	return h.service.CloseCategory(ctx, req)
+}
+
+func (h *BookGRPCHandler) ReopenCategory(ctx context.Context, req *api.ReopenCategoryRequest) (*api.ReopenCategoryResponse, error) {
+	if req.CategoryId == "" {
+		return nil, status.Error(codes.InvalidArgument, "category_id not provided")
+	}
+
+	return h.service.ReopenCategory(ctx, req)
+}
+
+func (h *BookGRPCHandler) ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error) {
+	return h.service.ListAuditEntries(ctx, req)
+}
+
+func (h *BookGRPCHandler) WatchBooks(req *api.WatchBooksRequest, stream api.BookService_WatchBooksServer) error {
+	return h.service.WatchBooks(stream.Context(), req, stream.Send)
+}
+
+func (h *BookGRPCHandler) ListCopies(ctx context.Context, req *api.ListCopiesRequest) (*api.ListCopiesResponse, error) {
+	if req.BookId == "" {
+		return nil, status.Error(codes.InvalidArgument, "book_id not provided")
+	}
+
+	res, err := h.copyService.ListCopies(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) AddCopy(ctx context.Context, req *api.AddCopyRequest) (*api.AddCopyResponse, error) {
+	if req.Copy == nil || req.Copy.BookId == "" || req.Copy.Barcode == "" {
+		return nil, status.Error(codes.InvalidArgument, "copy book_id and barcode cannot be empty")
+	}
+
+	res, err := h.copyService.AddCopy(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) RetireCopy(ctx context.Context, req *api.RetireCopyRequest) (*api.RetireCopyResponse, error) {
+	if req.CopyId == "" {
+		return nil, status.Error(codes.InvalidArgument, "copy_id not provided")
+	}
+
+	res, err := h.copyService.RetireCopy(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (h *BookGRPCHandler) RelocateCopy(ctx context.Context, req *api.RelocateCopyRequest) (*api.RelocateCopyResponse, error) {
+	if req.CopyId == "" || req.Location == "" {
+		return nil, status.Error(codes.InvalidArgument, "copy_id and location cannot be empty")
+	}
+
+	res, err := h.copyService.RelocateCopy(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type LoanGRPCHandler struct {
+	api.UnimplementedLoanServiceServer
+
+	service     service.LoanService
+	fineService service.FineService
+}
+
+func NewLoanGRPCHandler(server *grpc.Server, service service.LoanService, fineService service.FineService) {
+	handler := &LoanGRPCHandler{
+		service:     service,
+		fineService: fineService,
+	}
+
+	api.RegisterLoanServiceServer(server, handler)
+}
+
+func (h *LoanGRPCHandler) CheckoutCopy(ctx context.Context, req *api.CheckoutCopyRequest) (*api.CheckoutCopyResponse, error) {
+	if req.CopyId == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "copy_id and user_id cannot be empty")
+	}
+
+	return h.service.CheckoutCopy(ctx, req)
+}
+
+func (h *LoanGRPCHandler) ReturnCopy(ctx context.Context, req *api.ReturnCopyRequest) (*api.ReturnCopyResponse, error) {
+	if req.CopyId == "" {
+		return nil, status.Error(codes.InvalidArgument, "copy_id not provided")
+	}
+
+	return h.service.ReturnCopy(ctx, req)
+}
+
+func (h *LoanGRPCHandler) RenewLoan(ctx context.Context, req *api.RenewLoanRequest) (*api.RenewLoanResponse, error) {
+	if req.LoanId == "" {
+		return nil, status.Error(codes.InvalidArgument, "loan_id not provided")
+	}
+
+	return h.service.RenewLoan(ctx, req)
+}
+
+func (h *LoanGRPCHandler) ListLoans(ctx context.Context, req *api.ListLoansRequest) (*api.ListLoansResponse, error) {
+	return h.service.ListLoans(ctx, req)
+}
+
+func (h *LoanGRPCHandler) PlaceHold(ctx context.Context, req *api.PlaceHoldRequest) (*api.PlaceHoldResponse, error) {
+	if req.BookId == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "book_id and user_id cannot be empty")
+	}
+
+	return h.service.PlaceHold(ctx, req)
+}
+
+func (h *LoanGRPCHandler) CancelHold(ctx context.Context, req *api.CancelHoldRequest) (*api.CancelHoldResponse, error) {
+	if req.HoldId == "" {
+		return nil, status.Error(codes.InvalidArgument, "hold_id not provided")
+	}
+
+	return h.service.CancelHold(ctx, req)
+}
+
+func (h *LoanGRPCHandler) GetHold(ctx context.Context, req *api.GetHoldRequest) (*api.GetHoldResponse, error) {
+	if req.HoldId == "" {
+		return nil, status.Error(codes.InvalidArgument, "hold_id not provided")
+	}
+
+	return h.service.GetHold(ctx, req)
+}
+
+func (h *LoanGRPCHandler) ListHolds(ctx context.Context, req *api.ListHoldsRequest) (*api.ListHoldsResponse, error) {
+	return h.service.ListHolds(ctx, req)
+}
+
+func (h *LoanGRPCHandler) GetBalance(ctx context.Context, req *api.GetBalanceRequest) (*api.GetBalanceResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id not provided")
+	}
+
+	return h.fineService.GetBalance(ctx, req)
+}
+
+func (h *LoanGRPCHandler) ListLedger(ctx context.Context, req *api.ListLedgerRequest) (*api.ListLedgerResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id not provided")
+	}
+
+	return h.fineService.ListLedger(ctx, req)
+}
+
+func (h *LoanGRPCHandler) WaiveFine(ctx context.Context, req *api.WaiveFineRequest) (*api.WaiveFineResponse, error) {
+	if req.UserId == "" || req.AmountCents <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and a positive amount_cents are required")
+	}
+
+	return h.fineService.WaiveFine(ctx, req)
+}
+
+func (h *LoanGRPCHandler) RecordPayment(ctx context.Context, req *api.RecordPaymentRequest) (*api.RecordPaymentResponse, error) {
+	if req.UserId == "" || req.AmountCents <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and a positive amount_cents are required")
+	}
+
+	return h.fineService.RecordPayment(ctx, req)
+}
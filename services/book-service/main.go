@@ -3,24 +3,35 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
 	"time"
 
+	api "github.com/daffaromero/gobook/protobuf/api"
 	"github.com/daffaromero/gobook/services/book-service/config"
 	"github.com/daffaromero/gobook/services/book-service/controller"
+	"github.com/daffaromero/gobook/services/book-service/migrations"
 	"github.com/daffaromero/gobook/services/book-service/repository"
 	"github.com/daffaromero/gobook/services/book-service/repository/query"
 	"github.com/daffaromero/gobook/services/book-service/service"
+	"github.com/daffaromero/gobook/services/common/audit"
+	"github.com/daffaromero/gobook/services/common/auth"
 	"github.com/daffaromero/gobook/services/common/discovery"
 	"github.com/daffaromero/gobook/services/common/discovery/consul"
+	"github.com/daffaromero/gobook/services/common/errs"
 	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/daffaromero/gobook/services/common/migrate"
+	"github.com/daffaromero/gobook/services/common/outbox"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/gofiber/fiber/v3/middleware/expvar"
 	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 var logs = logger.New("main")
@@ -28,10 +39,23 @@ var logs = logger.New("main")
 func webServer() error {
 	app := fiber.New()
 	app.Use(requestid.New())
+	app.Use(audit.Middleware())
 
 	serverConfig := config.NewServerConfig()
 	dbConfig := config.NewPostgresDatabase()
 	store := repository.NewStore(dbConfig)
+
+	if config.AutoMigrate {
+		migrator, err := migrate.New(dbConfig, migrations.FS)
+		if err != nil {
+			logs.Error(fmt.Sprintf("Failed to load migrations: %v", err))
+			return err
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			logs.Error(fmt.Sprintf("Failed to apply migrations: %v", err))
+			return err
+		}
+	}
 	validate := validator.New()
 
 	registry, err := consul.NewRegistry(serverConfig.ConsulAddr, serverConfig.Name)
@@ -40,17 +64,46 @@ func webServer() error {
 		return err
 	}
 
+	GRPCserviceID := discovery.GenerateServiceID(serverConfig.Name + "-grpc")
 	HTTPserviceID := discovery.GenerateServiceID(serverConfig.Name + "-http")
+
+	grpcPortInt, _ := strconv.Atoi(serverConfig.GRPCPort)
 	httpPortInt, _ := strconv.Atoi(serverConfig.HTTPPort)
 
 	ctx := context.Background()
 
+	err = registry.RegisterService(ctx, serverConfig.Name+"-grpc", GRPCserviceID, serverConfig.GRPCAddr, grpcPortInt, []string{"grpc"})
+	if err != nil {
+		logs.Error("Failed to register gRPC book service to consul")
+		return err
+	}
+
 	err = registry.RegisterService(ctx, serverConfig.Name, HTTPserviceID, serverConfig.HTTP, httpPortInt, []string{"http"})
 	if err != nil {
 		logs.Error("Failed to register HTTP book service to consul")
 		return err
 	}
 
+	go func() {
+		failureCount := 0
+		const maxFailures = 5
+		for {
+			err := registry.HealthCheck(GRPCserviceID, serverConfig.Name+"-grpc")
+			if err != nil {
+				logs.Error(fmt.Sprintf("Failed to perform health check for gRPC service: %v", err))
+				failureCount++
+				if failureCount >= maxFailures {
+					logs.Error("Max health check failures reached for gRPC service. Exiting health check loop.")
+					break
+				}
+			} else {
+				failureCount = 0
+			}
+			time.Sleep(time.Second * 2)
+		}
+	}()
+	defer registry.DeregisterService(ctx, GRPCserviceID)
+
 	go func() {
 		failureCount := 0
 		const maxFailures = 5
@@ -72,17 +125,130 @@ func webServer() error {
 	defer registry.DeregisterService(ctx, HTTPserviceID)
 
 	bookQuery := query.NewBookQuery(dbConfig)
-	bookRepo := repository.NewBookRepository(store, bookQuery)
-	bookService, err := service.NewBookService(ctx, registry, bookRepo, logs)
+	auditQuery := audit.NewQuery(dbConfig)
+	feed := outbox.NewFeed(dbConfig, logs)
+	go feed.Run(ctx, config.OutboxRelayInterval)
+	bookRepo := repository.NewBookRepository(store, bookQuery, auditQuery, feed)
+	cacheConfig := service.CategoryCacheConfig{
+		TTL:         config.CategoryCacheTTL,
+		NegativeTTL: config.CategoryCacheNegativeTTL,
+		MaxStale:    config.CategoryCacheMaxStale,
+		MaxEntries:  config.CategoryCacheMaxEntries,
+	}
+	categories, err := service.NewCategoryCache(ctx, registry, cacheConfig, logs)
+	if err != nil {
+		logs.Error("Failed to create category cache")
+		return err
+	}
+	go categories.Watch(ctx)
+	bookService := service.NewBookService(categories, bookRepo, logs)
+
+	go func() {
+		// Permanently remove books that have been in the trash too long.
+		ticker := time.NewTicker(config.TrashPurgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			bookService.PurgeBooks(ctx, config.TrashRetention)
+		}
+	}()
+
+	publisher, err := outbox.NewPublisher(config.OutboxPublisher, config.OutboxURL(), logs)
 	if err != nil {
-		logs.Error("Failed to create book service")
+		logs.Error(fmt.Sprintf("Failed to create outbox publisher: %v", err))
 		return err
 	}
-	bookController := controller.NewBookController(validate, bookService)
+	relay := outbox.NewRelay(dbConfig, publisher, logs, config.OutboxBatchSize, config.OutboxRetention)
+	go relay.Run(ctx, config.OutboxRelayInterval)
 
-	logs.Log(fmt.Sprintf("Starting HTTP category server on %s", serverConfig.HTTP))
-	app.Use(cors.New())
+	loanRules := query.LoanRules{
+		PeriodDays:     config.LoanPeriodDays,
+		MaxRenewals:    config.LoanMaxRenewals,
+		MaxActive:      config.LoanMaxActive,
+		HoldPickupDays: config.HoldPickupDays,
+		FineBlockCents: config.FineBlockCents,
+	}
+	copyQuery := query.NewCopyQuery(dbConfig, loanRules)
+	copyRepo := repository.NewCopyRepository(store, copyQuery)
+	copyService := service.NewCopyService(copyRepo, logs)
+	loanQuery := query.NewLoanQuery(dbConfig, loanRules)
+	loanRepo := repository.NewLoanRepository(store, loanQuery)
+	holdQuery := query.NewHoldQuery(dbConfig, loanRules)
+	holdRepo := repository.NewHoldRepository(store, holdQuery)
+	loanService, err := service.NewLoanService(ctx, registry, loanRepo, holdRepo, logs)
+	if err != nil {
+		logs.Error("Failed to create loan service")
+		return err
+	}
+
+	go func() {
+		// Expire holds that were not picked up in time.
+		ticker := time.NewTicker(config.HoldExpiryInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			loanService.ExpireHolds(ctx)
+		}
+	}()
+
+	fineQuery := query.NewFineQuery(dbConfig)
+	fineRepo := repository.NewFineRepository(store, fineQuery)
+	finePolicy := &api.FinePolicy{
+		DailyRateCents: config.FineDailyRateCents,
+		GraceDays:      config.FineGraceDays,
+		CapCents:       config.FineCapCents,
+	}
+	fineService := service.NewFineService(categories, finePolicy, fineRepo, logs)
+
+	go func() {
+		// Bring overdue fines up to date.
+		ticker := time.NewTicker(config.FineAccrualInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			fineService.AccrueFines(ctx)
+		}
+	}()
+
+	tokenValidator, err := auth.NewValidator(ctx, config.NewAuthConfig(), registry)
+	if err != nil {
+		logs.Error(fmt.Sprintf("Failed to create token validator: %v", err))
+		return err
+	}
+	policy := auth.NewPolicy(config.AccessRules)
+	bookController := controller.NewBookController(validate, bookService, auth.Middleware(tokenValidator), policy)
+	copyController := controller.NewCopyController(validate, copyService, auth.Middleware(tokenValidator), policy)
+	loanController := controller.NewLoanController(validate, loanService, auth.Middleware(tokenValidator), policy)
+	fineController := controller.NewFineController(fineService, auth.Middleware(tokenValidator), policy)
+
+	go func() {
+		// gRPC server + reflection
+		grpcServer := newGRPCServer(tokenValidator, policy)
+		reflection.Register(grpcServer)
+
+		l, err := net.Listen("tcp", serverConfig.GRPC)
+		if err != nil {
+			// The service cannot do without its gRPC API, so stop it here.
+			logs.Error(fmt.Sprintf("Failed to listen: %v", err))
+			os.Exit(1)
+		}
+		logs.Log(fmt.Sprintf("gRPC server started on %s", serverConfig.GRPC))
+		defer l.Close()
+
+		NewBookGRPCHandler(grpcServer, bookService, copyService)
+		NewLoanGRPCHandler(grpcServer, loanService, fineService)
+
+		if err := grpcServer.Serve(l); err != nil {
+			logs.Error(fmt.Sprintf("Failed to start gRPC book server: %v", err))
+		}
+	}()
+
+	logs.Log(fmt.Sprintf("Starting HTTP book server on %s", serverConfig.HTTP))
+	// Browsers only let scripts read the ETag needed for If-Match if it is exposed.
+	app.Use(cors.New(cors.Config{ExposeHeaders: []string{fiber.HeaderETag}}))
+	fineController.Route(app)
+	loanController.Route(app)
+	copyController.Route(app)
 	bookController.Route(app)
+	// Runtime stats and the state of each gRPC client circuit breaker.
+	app.Get("/debug/vars", expvar.New(), auth.Middleware(tokenValidator), policy.Require("ViewMetrics"))
 
 	err = app.Listen(serverConfig.HTTP, fiber.ListenConfig{
 		DisableStartupMessage: true,
@@ -94,7 +260,40 @@ func webServer() error {
 	return nil
 }
 
+// newGRPCServer returns a gRPC server that authenticates callers with tokens
+// and enforces policy on every call.
+func newGRPCServer(tokens auth.Validator, policy *auth.Policy) *grpc.Server {
+	return grpc.NewServer(grpc.ChainUnaryInterceptor(
+		errs.UnaryServerInterceptor(),
+		audit.UnaryServerInterceptor(),
+		auth.UnaryServerInterceptor(tokens),
+		policy.UnaryServerInterceptor(),
+	), grpc.ChainStreamInterceptor(
+		errs.StreamServerInterceptor(),
+		auth.StreamServerInterceptor(tokens),
+		policy.StreamServerInterceptor(),
+	))
+}
+
+// migrateCommand handles "migrate up|down [steps]|status" without starting
+// the servers.
+func migrateCommand(args []string) error {
+	migrator, err := migrate.New(config.NewPostgresDatabase(), migrations.FS)
+	if err != nil {
+		return err
+	}
+	return migrator.Run(context.Background(), args, os.Stdout)
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrateCommand(os.Args[2:]); err != nil {
+			logs.Error(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := webServer(); err != nil {
 		logs.Error(err)
 	}
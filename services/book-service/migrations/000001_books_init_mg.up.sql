@@ -1,4 +1,4 @@
-CREATE TABLE books (
+CREATE TABLE IF NOT EXISTS books (
   "id" uuid PRIMARY KEY,
   "title" VARCHAR(255) NOT NULL,
   "author" VARCHAR(255) NOT NULL,
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS books_updated_at_id_idx;
+DROP INDEX IF EXISTS books_created_at_id_idx;
+DROP INDEX IF EXISTS books_author_id_idx;
+DROP INDEX IF EXISTS books_title_id_idx;
+
+ALTER TABLE books
+  ALTER COLUMN "created_at" DROP NOT NULL,
+  ALTER COLUMN "updated_at" DROP NOT NULL;
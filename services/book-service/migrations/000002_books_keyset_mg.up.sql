@@ -0,0 +1,11 @@
+UPDATE books SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+UPDATE books SET updated_at = created_at WHERE updated_at IS NULL;
+
+ALTER TABLE books
+  ALTER COLUMN "created_at" SET NOT NULL,
+  ALTER COLUMN "updated_at" SET NOT NULL;
+
+CREATE INDEX books_title_id_idx ON books (title, id) WHERE deleted_at IS NULL;
+CREATE INDEX books_author_id_idx ON books (author, id) WHERE deleted_at IS NULL;
+CREATE INDEX books_created_at_id_idx ON books (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX books_updated_at_id_idx ON books (updated_at, id) WHERE deleted_at IS NULL;
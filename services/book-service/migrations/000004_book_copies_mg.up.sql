@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS book_copies (
+  "id" uuid PRIMARY KEY,
+  "book_id" uuid NOT NULL REFERENCES books (id),
+  "barcode" VARCHAR(64) NOT NULL UNIQUE,
+  "condition" VARCHAR(32) NOT NULL DEFAULT 'good',
+  "location" VARCHAR(255) NOT NULL DEFAULT '',
+  "status" VARCHAR(32) NOT NULL DEFAULT 'available',
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "retired_at" TIMESTAMPTZ DEFAULT NULL,
+  CONSTRAINT book_copies_condition_check CHECK (condition IN ('new', 'good', 'fair', 'poor', 'damaged')),
+  CONSTRAINT book_copies_status_check CHECK (status IN ('available', 'on_loan', 'retired'))
+);
+
+CREATE INDEX IF NOT EXISTS book_copies_book_id_idx ON book_copies (book_id, status);
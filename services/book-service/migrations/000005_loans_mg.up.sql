@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS loans (
+  "id" uuid PRIMARY KEY,
+  "copy_id" uuid NOT NULL REFERENCES book_copies (id),
+  "book_id" uuid NOT NULL REFERENCES books (id),
+  "user_id" uuid NOT NULL,
+  "checked_out_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "due_at" TIMESTAMPTZ NOT NULL,
+  "returned_at" TIMESTAMPTZ DEFAULT NULL,
+  "renewals" INTEGER NOT NULL DEFAULT 0
+);
+
+-- A copy can only be out on one loan at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS loans_active_copy_idx ON loans (copy_id) WHERE returned_at IS NULL;
+CREATE INDEX IF NOT EXISTS loans_user_id_idx ON loans (user_id, checked_out_at DESC);
+CREATE INDEX IF NOT EXISTS loans_book_id_idx ON loans (book_id, checked_out_at DESC);
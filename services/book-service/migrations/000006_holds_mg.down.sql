@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS holds;
+
+UPDATE book_copies SET status = 'available' WHERE status = 'on_hold';
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check CHECK (status IN ('available', 'on_loan', 'retired'));
@@ -0,0 +1,21 @@
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check CHECK (status IN ('available', 'on_loan', 'on_hold', 'retired'));
+
+CREATE TABLE IF NOT EXISTS holds (
+  "id" uuid PRIMARY KEY,
+  "book_id" uuid NOT NULL REFERENCES books (id),
+  "user_id" uuid NOT NULL,
+  "status" VARCHAR(32) NOT NULL DEFAULT 'waiting',
+  "copy_id" uuid REFERENCES book_copies (id),
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "ready_at" TIMESTAMPTZ DEFAULT NULL,
+  "expires_at" TIMESTAMPTZ DEFAULT NULL,
+  CONSTRAINT holds_status_check CHECK (status IN ('waiting', 'ready', 'fulfilled', 'cancelled', 'expired'))
+);
+
+-- One open hold per member and book.
+CREATE UNIQUE INDEX IF NOT EXISTS holds_active_user_book_idx ON holds (book_id, user_id) WHERE status IN ('waiting', 'ready');
+CREATE INDEX IF NOT EXISTS holds_queue_idx ON holds (book_id, created_at, id) WHERE status = 'waiting';
+CREATE INDEX IF NOT EXISTS holds_ready_expiry_idx ON holds (expires_at) WHERE status = 'ready';
+CREATE INDEX IF NOT EXISTS holds_user_id_idx ON holds (user_id, created_at DESC);
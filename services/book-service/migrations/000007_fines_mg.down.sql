@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS fine_ledger;
+
+ALTER TABLE loans DROP COLUMN IF EXISTS "fines_closed_at";
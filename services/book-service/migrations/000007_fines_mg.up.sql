@@ -0,0 +1,17 @@
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS "fines_closed_at" TIMESTAMPTZ DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS fine_ledger (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL,
+  "loan_id" uuid REFERENCES loans (id),
+  "kind" VARCHAR(32) NOT NULL,
+  "amount_cents" BIGINT NOT NULL,
+  "note" TEXT NOT NULL DEFAULT '',
+  "created_by" uuid DEFAULT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT fine_ledger_kind_check CHECK (kind IN ('fine', 'waiver', 'payment')),
+  CONSTRAINT fine_ledger_sign_check CHECK ((kind = 'fine' AND amount_cents > 0) OR (kind <> 'fine' AND amount_cents < 0))
+);
+
+CREATE INDEX IF NOT EXISTS fine_ledger_user_id_idx ON fine_ledger (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS fine_ledger_loan_id_idx ON fine_ledger (loan_id) WHERE loan_id IS NOT NULL;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+  "id" uuid PRIMARY KEY,
+  "entity_type" VARCHAR(32) NOT NULL,
+  "entity_id" uuid NOT NULL,
+  "action" VARCHAR(16) NOT NULL,
+  "actor_id" uuid DEFAULT NULL,
+  "request_id" TEXT NOT NULL DEFAULT '',
+  "changes" JSONB NOT NULL DEFAULT '{}',
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT audit_log_action_check CHECK (action IN ('create', 'update', 'delete', 'restore'))
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_id, created_at DESC) WHERE actor_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at DESC);
+
+-- Entries are append-only; corrections are new entries.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+  FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
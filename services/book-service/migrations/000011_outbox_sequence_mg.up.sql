@@ -0,0 +1,14 @@
+-- The relay numbers events as it picks them up, so watchers can follow and
+-- resume the stream of changes in order.
+CREATE SEQUENCE IF NOT EXISTS outbox_sequence;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS "sequence" BIGINT DEFAULT NULL;
+
+UPDATE outbox o SET sequence = n.sequence
+FROM (
+  SELECT id, row_number() OVER (ORDER BY published_at, created_at, id) AS sequence
+  FROM outbox WHERE published_at IS NOT NULL
+) n
+WHERE o.id = n.id;
+SELECT setval('outbox_sequence', COALESCE((SELECT max(sequence) FROM outbox), 0) + 1, false);
+
+CREATE UNIQUE INDEX IF NOT EXISTS outbox_sequence_idx ON outbox (sequence) WHERE sequence IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Loans of purged books have nothing left to refer to, so they are dropped;
+-- their fines stay on the ledger without a loan.
+UPDATE fine_ledger SET loan_id = NULL WHERE loan_id IN (SELECT id FROM loans WHERE book_id IS NULL OR copy_id IS NULL);
+DELETE FROM loans WHERE book_id IS NULL OR copy_id IS NULL;
+
+ALTER TABLE loans DROP COLUMN IF EXISTS "copy_barcode";
+ALTER TABLE loans DROP COLUMN IF EXISTS "book_title";
+ALTER TABLE loans ALTER COLUMN "copy_id" SET NOT NULL;
+ALTER TABLE loans ALTER COLUMN "book_id" SET NOT NULL;
@@ -0,0 +1,6 @@
+-- Loans are kept as history after their book is purged from the trash. The
+-- purge clears book_id and copy_id and keeps the title and barcode instead.
+ALTER TABLE loans ALTER COLUMN "book_id" DROP NOT NULL;
+ALTER TABLE loans ALTER COLUMN "copy_id" DROP NOT NULL;
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS "book_title" VARCHAR(255) DEFAULT NULL;
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS "copy_barcode" VARCHAR(64) DEFAULT NULL;
@@ -0,0 +1,7 @@
+-- Categories that book-category-service is deleting. Books cannot be put in
+-- them until they are reopened, however stale a book-service instance's view
+-- of the category is.
+CREATE TABLE IF NOT EXISTS closed_categories (
+  "category_id" uuid PRIMARY KEY,
+  "closed_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -3,9 +3,14 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	api "github.com/daffaromero/gobook/protobuf/api"
 	"github.com/daffaromero/gobook/services/book-service/repository/query"
+	"github.com/daffaromero/gobook/services/common/audit"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/outbox"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -13,23 +18,66 @@ import (
 type BookRepository interface {
 	GetBook(ctx context.Context, req *api.GetBookRequest) (*api.GetBookResponse, error)
 	ListBooks(ctx context.Context, req *api.ListBooksRequest) (*api.ListBooksResponse, error)
+	SearchBooks(ctx context.Context, req *api.SearchBooksRequest) (*api.SearchBooksResponse, error)
 	CreateBook(ctx context.Context, req *api.CreateBookRequest) (*api.CreateBookResponse, error)
 	UpdateBook(ctx context.Context, req *api.UpdateBookRequest) (*api.UpdateBookResponse, error)
 	DeleteBook(ctx context.Context, id *api.DeleteBookRequest) (*api.DeleteBookResponse, error)
+	ListDeletedBooks(ctx context.Context, req *api.ListDeletedBooksRequest) (*api.ListDeletedBooksResponse, error)
+	GetDeletedBook(ctx context.Context, id string) (*api.Book, error)
+	RestoreBook(ctx context.Context, req *api.RestoreBookRequest) (*api.RestoreBookResponse, error)
+	PurgeBooks(ctx context.Context, before time.Time) (query.PurgeResult, error)
+	CountBooksByCategory(ctx context.Context, categoryID string) (int64, error)
+	ReassignCategory(ctx context.Context, fromID, toID string) (int64, error)
+	DeleteBooksByCategory(ctx context.Context, categoryID string) (int64, error)
+	CloseCategory(ctx context.Context, categoryID string, mode api.DeleteCategoryMode, fallbackID string) (int64, error)
+	ReopenCategory(ctx context.Context, categoryID string) error
+	ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error)
+	WatchBooks(ctx context.Context, afterSequence int64, send func(outbox.Event) error) error
 }
 
 type bookRepository struct {
-	db        Store
-	bookQuery query.BookQuery
+	db         Store
+	bookQuery  query.BookQuery
+	auditQuery audit.Query
+	feed       *outbox.Feed
 }
 
-func NewBookRepository(db Store, bookQuery query.BookQuery) BookRepository {
+func NewBookRepository(db Store, bookQuery query.BookQuery, auditQuery audit.Query, feed *outbox.Feed) BookRepository {
 	return &bookRepository{
-		db:        db,
-		bookQuery: bookQuery,
+		db:         db,
+		bookQuery:  bookQuery,
+		auditQuery: auditQuery,
+		feed:       feed,
 	}
 }
 
+// record appends an audit entry for a change to a book within tx, and queues
+// the matching event for the outbox relay. before is nil for creates and
+// restores, after for deletes.
+func (r *bookRepository) record(ctx context.Context, tx pgx.Tx, action string, before, after *api.Book) error {
+	id, state := after.GetId(), after
+	if before != nil {
+		id = before.Id
+	}
+	if after == nil {
+		state = before
+	}
+
+	entry, err := audit.NewEntry(ctx, query.EntityBook, id, action, before, after)
+	if err != nil {
+		return fmt.Errorf("failed to build audit entry: %w", err)
+	}
+	if err := r.auditQuery.Record(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	event, err := outbox.NewEvent(ctx, outbox.Topic(query.EntityBook, action), id, state)
+	if err != nil {
+		return err
+	}
+	return outbox.Enqueue(ctx, tx, event)
+}
+
 func (r *bookRepository) GetBook(ctx context.Context, req *api.GetBookRequest) (*api.GetBookResponse, error) {
 	var book *api.GetBookResponse
 
@@ -58,13 +106,33 @@ func (r *bookRepository) ListBooks(ctx context.Context, req *api.ListBooksReques
 	return books, nil
 }
 
+func (r *bookRepository) SearchBooks(ctx context.Context, req *api.SearchBooksRequest) (*api.SearchBooksResponse, error) {
+	var results *api.SearchBooksResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		results, err = r.bookQuery.SearchBooks(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search books: %w", err)
+	}
+	return results, nil
+}
+
 func (r *bookRepository) CreateBook(ctx context.Context, req *api.CreateBookRequest) (*api.CreateBookResponse, error) {
 	var book *api.CreateBookResponse
 
 	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := r.bookQuery.CheckCategoryOpen(ctx, tx, req.GetBook().GetCategoryId()); err != nil {
+			return err
+		}
 		var err error
 		book, err = r.bookQuery.CreateBook(ctx, tx, req)
-		return err
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, tx, audit.Create, nil, book.Book)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create book: %w", err)
@@ -76,9 +144,20 @@ func (r *bookRepository) UpdateBook(ctx context.Context, req *api.UpdateBookRequ
 	var book *api.UpdateBookResponse
 
 	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
-		var err error
+		if slices.Contains(req.GetUpdateMask().GetPaths(), "category_id") {
+			if err := r.bookQuery.CheckCategoryOpen(ctx, tx, req.Book.CategoryId); err != nil {
+				return err
+			}
+		}
+		before, err := r.bookQuery.LockBook(ctx, tx, req.GetBook().GetId())
+		if err != nil {
+			return err
+		}
 		book, err = r.bookQuery.UpdateBook(ctx, tx, req)
-		return err
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, tx, audit.Update, before, book.Book)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update book: %w", err)
@@ -90,12 +169,235 @@ func (r *bookRepository) DeleteBook(ctx context.Context, id *api.DeleteBookReque
 	var res *api.DeleteBookResponse
 
 	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
-		var err error
+		before, err := r.bookQuery.LockBook(ctx, tx, id.BookId)
+		if err != nil {
+			return err
+		}
 		res, err = r.bookQuery.DeleteBook(ctx, tx, id)
-		return err
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, tx, audit.Delete, before, nil)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete book: %w", err)
 	}
 	return res, nil
 }
+
+func (r *bookRepository) ListDeletedBooks(ctx context.Context, req *api.ListDeletedBooksRequest) (*api.ListDeletedBooksResponse, error) {
+	var books *api.ListDeletedBooksResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		books, err = r.bookQuery.ListDeletedBooks(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted books: %w", err)
+	}
+	return books, nil
+}
+
+func (r *bookRepository) GetDeletedBook(ctx context.Context, id string) (*api.Book, error) {
+	var book *api.Book
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		book, err = r.bookQuery.GetDeletedBook(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted book: %w", err)
+	}
+	return book, nil
+}
+
+func (r *bookRepository) RestoreBook(ctx context.Context, req *api.RestoreBookRequest) (*api.RestoreBookResponse, error) {
+	var res *api.RestoreBookResponse
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		deleted, err := r.bookQuery.GetDeletedBook(ctx, req.BookId)
+		if err != nil {
+			return err
+		}
+		if deleted.CategoryId != "" {
+			if err := r.bookQuery.CheckCategoryOpen(ctx, tx, deleted.CategoryId); err != nil {
+				return err
+			}
+		}
+		res, err = r.bookQuery.RestoreBook(ctx, tx, req)
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, tx, audit.Restore, nil, res.Book)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore book: %w", err)
+	}
+	return res, nil
+}
+
+func (r *bookRepository) PurgeBooks(ctx context.Context, before time.Time) (query.PurgeResult, error) {
+	var purged query.PurgeResult
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		purged, err = r.bookQuery.PurgeBooks(ctx, tx, before)
+		return err
+	})
+	if err != nil {
+		return query.PurgeResult{}, fmt.Errorf("failed to purge books: %w", err)
+	}
+	return purged, nil
+}
+
+func (r *bookRepository) CountBooksByCategory(ctx context.Context, categoryID string) (int64, error) {
+	var count int64
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		count, err = r.bookQuery.CountBooksByCategory(ctx, categoryID)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count books: %w", err)
+	}
+	return count, nil
+}
+
+func (r *bookRepository) ReassignCategory(ctx context.Context, fromID, toID string) (int64, error) {
+	var moved int64
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		moved, err = r.reassign(ctx, tx, fromID, toID)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to reassign books: %w", err)
+	}
+	return moved, nil
+}
+
+func (r *bookRepository) DeleteBooksByCategory(ctx context.Context, categoryID string) (int64, error) {
+	var deleted int64
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		deleted, err = r.deleteByCategory(ctx, tx, categoryID)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete books: %w", err)
+	}
+	return deleted, nil
+}
+
+// CloseCategory closes a category to new books and, in the same transaction,
+// refuses if books remain in it, moves them to fallbackID, or deletes them,
+// according to mode. It returns how many books it moved or deleted.
+func (r *bookRepository) CloseCategory(ctx context.Context, categoryID string, mode api.DeleteCategoryMode, fallbackID string) (int64, error) {
+	var affected int64
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := r.bookQuery.CloseCategory(ctx, tx, categoryID); err != nil {
+			return err
+		}
+
+		var err error
+		switch mode {
+		case api.DeleteCategoryMode_DELETE_CATEGORY_MODE_REASSIGN:
+			affected, err = r.reassign(ctx, tx, categoryID, fallbackID)
+		case api.DeleteCategoryMode_DELETE_CATEGORY_MODE_CASCADE:
+			affected, err = r.deleteByCategory(ctx, tx, categoryID)
+		default:
+			var books []*api.Book
+			books, err = r.bookQuery.LockBooksByCategory(ctx, tx, categoryID)
+			if err == nil && len(books) > 0 {
+				err = errs.Newf(errs.Conflict, "category with ID %s still has %d books", categoryID, len(books))
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to close category: %w", err)
+	}
+	return affected, nil
+}
+
+func (r *bookRepository) ReopenCategory(ctx context.Context, categoryID string) error {
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		return r.bookQuery.ReopenCategory(ctx, tx, categoryID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reopen category: %w", err)
+	}
+	return nil
+}
+
+// reassign moves the live books in fromID to toID within tx, recording an
+// update for each, and returns how many it moved.
+func (r *bookRepository) reassign(ctx context.Context, tx pgx.Tx, fromID, toID string) (int64, error) {
+	if err := r.bookQuery.CheckCategoryOpen(ctx, tx, toID); err != nil {
+		return 0, err
+	}
+	before, err := r.bookQuery.LockBooksByCategory(ctx, tx, fromID)
+	if err != nil {
+		return 0, err
+	}
+	after, err := r.bookQuery.ReassignCategory(ctx, tx, fromID, toID)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := make(map[string]*api.Book, len(after))
+	for _, book := range after {
+		updated[book.Id] = book
+	}
+	for _, book := range before {
+		if err := r.record(ctx, tx, audit.Update, book, updated[book.Id]); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(after)), nil
+}
+
+// deleteByCategory soft-deletes the live books in a category within tx,
+// recording a delete for each, and returns how many it deleted.
+func (r *bookRepository) deleteByCategory(ctx context.Context, tx pgx.Tx, categoryID string) (int64, error) {
+	before, err := r.bookQuery.LockBooksByCategory(ctx, tx, categoryID)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := r.bookQuery.DeleteBooksByCategory(ctx, tx, categoryID)
+	if err != nil {
+		return 0, err
+	}
+	for _, book := range before {
+		if err := r.record(ctx, tx, audit.Delete, book, nil); err != nil {
+			return 0, err
+		}
+	}
+	return deleted, nil
+}
+
+func (r *bookRepository) ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error) {
+	var entries *api.ListAuditEntriesResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		entries, err = r.auditQuery.ListAuditEntries(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// WatchBooks follows the events recorded with each change. It holds no
+// transaction, as it may run for as long as the caller stays connected.
+func (r *bookRepository) WatchBooks(ctx context.Context, afterSequence int64, send func(outbox.Event) error) error {
+	return r.feed.Watch(ctx, afterSequence, send)
+}
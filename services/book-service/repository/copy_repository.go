@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/repository/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CopyRepository interface {
+	ListCopies(ctx context.Context, req *api.ListCopiesRequest) (*api.ListCopiesResponse, error)
+	AddCopy(ctx context.Context, req *api.AddCopyRequest) (*api.AddCopyResponse, error)
+	RetireCopy(ctx context.Context, req *api.RetireCopyRequest) (*api.RetireCopyResponse, error)
+	RelocateCopy(ctx context.Context, req *api.RelocateCopyRequest) (*api.RelocateCopyResponse, error)
+}
+
+type copyRepository struct {
+	db        Store
+	copyQuery query.CopyQuery
+}
+
+func NewCopyRepository(db Store, copyQuery query.CopyQuery) CopyRepository {
+	return &copyRepository{
+		db:        db,
+		copyQuery: copyQuery,
+	}
+}
+
+func (r *copyRepository) ListCopies(ctx context.Context, req *api.ListCopiesRequest) (*api.ListCopiesResponse, error) {
+	var copies *api.ListCopiesResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		copies, err = r.copyQuery.ListCopies(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list copies: %w", err)
+	}
+	return copies, nil
+}
+
+func (r *copyRepository) AddCopy(ctx context.Context, req *api.AddCopyRequest) (*api.AddCopyResponse, error) {
+	var res *api.AddCopyResponse
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.copyQuery.AddCopy(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add copy: %w", err)
+	}
+	return res, nil
+}
+
+func (r *copyRepository) RetireCopy(ctx context.Context, req *api.RetireCopyRequest) (*api.RetireCopyResponse, error) {
+	var res *api.RetireCopyResponse
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.copyQuery.RetireCopy(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retire copy: %w", err)
+	}
+	return res, nil
+}
+
+func (r *copyRepository) RelocateCopy(ctx context.Context, req *api.RelocateCopyRequest) (*api.RelocateCopyResponse, error) {
+	var res *api.RelocateCopyResponse
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.copyQuery.RelocateCopy(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to relocate copy: %w", err)
+	}
+	return res, nil
+}
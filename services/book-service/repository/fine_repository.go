@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/repository/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FineRepository interface {
+	ListFineCandidates(ctx context.Context) ([]query.FineCandidate, error)
+	ChargeFine(ctx context.Context, loan query.FineCandidate, total int64) (int64, error)
+	GetBalance(ctx context.Context, userID string) (int64, error)
+	ListLedger(ctx context.Context, req *api.ListLedgerRequest) (*api.ListLedgerResponse, error)
+	Credit(ctx context.Context, entry *api.LedgerEntry) (*api.LedgerEntry, error)
+}
+
+type fineRepository struct {
+	db        Store
+	fineQuery query.FineQuery
+}
+
+func NewFineRepository(db Store, fineQuery query.FineQuery) FineRepository {
+	return &fineRepository{
+		db:        db,
+		fineQuery: fineQuery,
+	}
+}
+
+func (r *fineRepository) ListFineCandidates(ctx context.Context) ([]query.FineCandidate, error) {
+	var candidates []query.FineCandidate
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		candidates, err = r.fineQuery.ListFineCandidates(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue loans: %w", err)
+	}
+	return candidates, nil
+}
+
+func (r *fineRepository) ChargeFine(ctx context.Context, loan query.FineCandidate, total int64) (int64, error) {
+	var charged int64
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		charged, err = r.fineQuery.ChargeFine(ctx, tx, loan, total)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to charge fine: %w", err)
+	}
+	return charged, nil
+}
+
+func (r *fineRepository) GetBalance(ctx context.Context, userID string) (int64, error) {
+	var cents int64
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		cents, err = r.fineQuery.GetBalance(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return cents, nil
+}
+
+func (r *fineRepository) ListLedger(ctx context.Context, req *api.ListLedgerRequest) (*api.ListLedgerResponse, error) {
+	var ledger *api.ListLedgerResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		ledger, err = r.fineQuery.ListLedger(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger: %w", err)
+	}
+	return ledger, nil
+}
+
+func (r *fineRepository) Credit(ctx context.Context, entry *api.LedgerEntry) (*api.LedgerEntry, error) {
+	var res *api.LedgerEntry
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.fineQuery.Credit(ctx, tx, entry)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record %s: %w", entry.Kind, err)
+	}
+	return res, nil
+}
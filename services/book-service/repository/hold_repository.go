@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/repository/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type HoldRepository interface {
+	GetHold(ctx context.Context, req *api.GetHoldRequest) (*api.Hold, error)
+	ListHolds(ctx context.Context, req *api.ListHoldsRequest) (*api.ListHoldsResponse, error)
+	PlaceHold(ctx context.Context, hold *api.Hold) (*api.Hold, error)
+	CancelHold(ctx context.Context, req *api.CancelHoldRequest) (*api.Hold, error)
+	ExpireHolds(ctx context.Context) (int, error)
+}
+
+type holdRepository struct {
+	db        Store
+	holdQuery query.HoldQuery
+}
+
+func NewHoldRepository(db Store, holdQuery query.HoldQuery) HoldRepository {
+	return &holdRepository{
+		db:        db,
+		holdQuery: holdQuery,
+	}
+}
+
+func (r *holdRepository) GetHold(ctx context.Context, req *api.GetHoldRequest) (*api.Hold, error) {
+	var hold *api.Hold
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		hold, err = r.holdQuery.GetHold(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	return hold, nil
+}
+
+func (r *holdRepository) ListHolds(ctx context.Context, req *api.ListHoldsRequest) (*api.ListHoldsResponse, error) {
+	var holds *api.ListHoldsResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		holds, err = r.holdQuery.ListHolds(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holds: %w", err)
+	}
+	return holds, nil
+}
+
+func (r *holdRepository) PlaceHold(ctx context.Context, hold *api.Hold) (*api.Hold, error) {
+	var res *api.Hold
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.holdQuery.PlaceHold(ctx, tx, hold)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to place hold: %w", err)
+	}
+	return res, nil
+}
+
+func (r *holdRepository) CancelHold(ctx context.Context, req *api.CancelHoldRequest) (*api.Hold, error) {
+	var res *api.Hold
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.holdQuery.CancelHold(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel hold: %w", err)
+	}
+	return res, nil
+}
+
+func (r *holdRepository) ExpireHolds(ctx context.Context) (int, error) {
+	var n int
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		n, err = r.holdQuery.ExpireHolds(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/repository/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LoanRepository interface {
+	ListLoans(ctx context.Context, req *api.ListLoansRequest) (*api.ListLoansResponse, error)
+	CheckoutCopy(ctx context.Context, loan *api.Loan) (*api.Loan, error)
+	ReturnCopy(ctx context.Context, req *api.ReturnCopyRequest) (*api.Loan, error)
+	RenewLoan(ctx context.Context, req *api.RenewLoanRequest) (*api.Loan, error)
+}
+
+type loanRepository struct {
+	db        Store
+	loanQuery query.LoanQuery
+}
+
+func NewLoanRepository(db Store, loanQuery query.LoanQuery) LoanRepository {
+	return &loanRepository{
+		db:        db,
+		loanQuery: loanQuery,
+	}
+}
+
+func (r *loanRepository) ListLoans(ctx context.Context, req *api.ListLoansRequest) (*api.ListLoansResponse, error) {
+	var loans *api.ListLoansResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		loans, err = r.loanQuery.ListLoans(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list loans: %w", err)
+	}
+	return loans, nil
+}
+
+func (r *loanRepository) CheckoutCopy(ctx context.Context, loan *api.Loan) (*api.Loan, error) {
+	var res *api.Loan
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.loanQuery.CheckoutCopy(ctx, tx, loan)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check out copy: %w", err)
+	}
+	return res, nil
+}
+
+func (r *loanRepository) ReturnCopy(ctx context.Context, req *api.ReturnCopyRequest) (*api.Loan, error) {
+	var res *api.Loan
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.loanQuery.ReturnCopy(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to return copy: %w", err)
+	}
+	return res, nil
+}
+
+func (r *loanRepository) RenewLoan(ctx context.Context, req *api.RenewLoanRequest) (*api.Loan, error) {
+	var res *api.Loan
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.loanQuery.RenewLoan(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew loan: %w", err)
+	}
+	return res, nil
+}
@@ -4,19 +4,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
+	"strings"
 	"time"
+	"unicode"
 
 	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/pagination"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// EntityBook is the audit log entity type for books.
+const EntityBook = "book"
+
 type BookQuery interface {
 	GetBook(ctx context.Context, req *api.GetBookRequest) (*api.GetBookResponse, error)
 	ListBooks(ctx context.Context, req *api.ListBooksRequest) (*api.ListBooksResponse, error)
+	SearchBooks(ctx context.Context, req *api.SearchBooksRequest) (*api.SearchBooksResponse, error)
 	CreateBook(ctx context.Context, tx pgx.Tx, req *api.CreateBookRequest) (*api.CreateBookResponse, error)
 	UpdateBook(ctx context.Context, tx pgx.Tx, req *api.UpdateBookRequest) (*api.UpdateBookResponse, error)
 	DeleteBook(ctx context.Context, tx pgx.Tx, id *api.DeleteBookRequest) (*api.DeleteBookResponse, error)
+	ListDeletedBooks(ctx context.Context, req *api.ListDeletedBooksRequest) (*api.ListDeletedBooksResponse, error)
+	GetDeletedBook(ctx context.Context, id string) (*api.Book, error)
+	RestoreBook(ctx context.Context, tx pgx.Tx, req *api.RestoreBookRequest) (*api.RestoreBookResponse, error)
+	PurgeBooks(ctx context.Context, tx pgx.Tx, before time.Time) (PurgeResult, error)
+	CountBooksByCategory(ctx context.Context, categoryID string) (int64, error)
+	ReassignCategory(ctx context.Context, tx pgx.Tx, fromID, toID string) ([]*api.Book, error)
+	DeleteBooksByCategory(ctx context.Context, tx pgx.Tx, categoryID string) (int64, error)
+	CloseCategory(ctx context.Context, tx pgx.Tx, categoryID string) error
+	ReopenCategory(ctx context.Context, tx pgx.Tx, categoryID string) error
+	CheckCategoryOpen(ctx context.Context, tx pgx.Tx, categoryID string) error
+	LockBook(ctx context.Context, tx pgx.Tx, id string) (*api.Book, error)
+	LockBooksByCategory(ctx context.Context, tx pgx.Tx, categoryID string) ([]*api.Book, error)
 }
 
 type bookQuery struct {
@@ -29,21 +51,31 @@ func NewBookQuery(db *pgxpool.Pool) *bookQuery {
 	}
 }
 
+// stockJoin joins the per-book copy counts onto the books table aliased as
+// %[1]s. Retired copies do not count towards stock.
+const stockJoin = `LEFT JOIN LATERAL (
+		SELECT COUNT(*) FILTER (WHERE status <> 'retired') AS total_copies,
+			COUNT(*) FILTER (WHERE status = 'available') AS available_copies
+		FROM book_copies WHERE book_id = %[1]s.id
+	) stock ON true`
+
 func (q *bookQuery) GetBook(ctx context.Context, req *api.GetBookRequest) (*api.GetBookResponse, error) {
 	if req == nil || req.BookId == "" {
-		return nil, errors.New("book ID cannot be empty")
+		return nil, errs.New(errs.InvalidArgument, "book ID cannot be empty")
 	}
-	query := `SELECT id, title, author, category_id, description FROM books WHERE id = $1 AND deleted_at IS NULL`
+	query := `SELECT id, title, author, category_id, description, version, stock.total_copies, stock.available_copies
+		FROM books ` + fmt.Sprintf(stockJoin, "books") + `
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	row := q.db.QueryRow(ctx, query, req.BookId)
 
 	var book api.Book
-	err := row.Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description)
+	err := row.Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description, &book.Version, &book.TotalCopies, &book.AvailableCopies)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("book with ID %s not found", req.BookId)
+			return nil, errs.Newf(errs.NotFound, "book with ID %s not found", req.BookId)
 		}
-		return nil, err
+		return nil, errs.DB(err)
 	}
 
 	return &api.GetBookResponse{
@@ -51,44 +83,223 @@ func (q *bookQuery) GetBook(ctx context.Context, req *api.GetBookRequest) (*api.
 	}, nil
 }
 
+var bookSortColumns = map[string]string{
+	"title":      "title",
+	"author":     "author",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// bookTimeColumns are the sort columns whose cursor values are timestamps.
+var bookTimeColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
 func (q *bookQuery) ListBooks(ctx context.Context, req *api.ListBooksRequest) (*api.ListBooksResponse, error) {
-	query := `SELECT id, title, author, category_id, description FROM books WHERE deleted_at IS NULL`
+	column, err := pagination.SortColumn(req.Sorting, bookSortColumns, "created_at")
+	if err != nil {
+		return nil, err
+	}
+	orderBy, err := pagination.OrderBy(req.Sorting, bookSortColumns, "created_at")
+	if err != nil {
+		return nil, err
+	}
+	limit, offset, err := pagination.Resolve(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	where := `WHERE deleted_at IS NULL`
+	args := []any{}
+	if req.Search != "" {
+		args = append(args, pagination.SearchPattern(req.Search))
+		where += ` AND (title ILIKE $1 OR author ILIKE $1)`
+	}
+
+	// Counting every matching book costs as much as listing them, so it is
+	// only done for the first page; clients keep the total from there.
+	var total int64
+	if req.PageToken == "" {
+		if err := q.db.QueryRow(ctx, `SELECT COUNT(*) FROM books `+where, args...).Scan(&total); err != nil {
+			return nil, fmt.Errorf("failed to count books: %w", err)
+		}
+	} else {
+		cursor, err := pagination.DecodeCursor(req.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		if !cursor.Matches(sortKey(req.Sorting), req.Sorting.GetIsReversed()) {
+			return nil, fmt.Errorf("%w: sort does not match the token", pagination.ErrInvalidCursor)
+		}
+
+		var value any = cursor.Value
+		if bookTimeColumns[column] {
+			if value, err = cursor.Time(); err != nil {
+				return nil, err
+			}
+		}
+
+		where += ` AND ` + cursor.After(column, len(args)+1)
+		args = append(args, value, cursor.ID)
+		offset = 0
+	}
 
-	rows, err := q.db.Query(ctx, query)
+	// One extra row tells us whether there is a next page.
+	query := fmt.Sprintf(`SELECT id, title, author, category_id, description, version, stock.total_copies, stock.available_copies, %s FROM books %s %s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		column, fmt.Sprintf(stockJoin, "books"), where, orderBy, len(args)+1, len(args)+2)
+	args = append(args, limit+1, offset)
+
+	rows, err := q.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query books: %w", err)
 	}
 	defer rows.Close()
 
 	var books []*api.Book
+	var lastKey any
+	var nextPageToken string
 	for rows.Next() {
+		if int32(len(books)) == limit {
+			last := books[len(books)-1]
+			nextPageToken = pagination.NewCursor(sortKey(req.Sorting), req.Sorting.GetIsReversed(), lastKey, last.Id).Encode()
+			break
+		}
+
 		var book api.Book
-		err := rows.Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description)
+		err := rows.Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description, &book.Version, &book.TotalCopies, &book.AvailableCopies, &lastKey)
 		if err != nil {
 			return nil, err
 		}
 		books = append(books, &book)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read books: %w", err)
+	}
+
 	return &api.ListBooksResponse{
 		Books: books,
+		PageInfo: &api.PageInfo{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// sortKey is the client-facing sort key a cursor is issued for.
+func sortKey(s *api.Sorting) string {
+	if s.GetOrderBy() == "" {
+		return "created_at"
+	}
+	return s.GetOrderBy()
+}
+
+var ErrEmptySearch = errs.New(errs.InvalidArgument, "search query has no searchable words")
+
+// tsQuery turns free text into a to_tsquery expression that requires every
+// word, with the last word matched as a prefix so partial input still hits.
+func tsQuery(term string) (string, error) {
+	words := strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "", ErrEmptySearch
+	}
+	words[len(words)-1] += ":*"
+	return strings.Join(words, " & "), nil
+}
+
+// ts_headline marks hits with these control characters, which are stripped
+// from the text beforehand, so highlight can tell them from the book's own.
+const (
+	hitStart = "\x01"
+	hitStop  = "\x02"
+)
+
+var hitMarker = strings.NewReplacer(hitStart, "<mark>", hitStop, "</mark>")
+
+// highlight turns a ts_headline fragment into HTML that is safe to render:
+// the book's text is escaped and only the hits are wrapped in <mark>.
+func highlight(snippet string) string {
+	return hitMarker.Replace(html.EscapeString(snippet))
+}
+
+func (q *bookQuery) SearchBooks(ctx context.Context, req *api.SearchBooksRequest) (*api.SearchBooksResponse, error) {
+	tsq, err := tsQuery(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset, err := pagination.Resolve(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM books WHERE deleted_at IS NULL AND search_vector @@ to_tsquery('english', $1)`
+	if err := q.db.QueryRow(ctx, countQuery, tsq).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	query := `WITH q AS (SELECT to_tsquery('english', $1) AS query)
+		SELECT b.id, b.title, b.author, b.category_id, b.description, b.version, stock.total_copies, stock.available_copies,
+			ts_rank_cd(b.search_vector, q.query) AS rank,
+			ts_headline('english', translate(concat_ws(' ', b.title, b.author, b.description), chr(1) || chr(2), ''), q.query,
+				'StartSel="' || chr(1) || '", StopSel="' || chr(2) || '", MaxWords=35, MinWords=15, MaxFragments=2') AS snippet
+		FROM books b CROSS JOIN q ` + fmt.Sprintf(stockJoin, "b") + `
+		WHERE b.deleted_at IS NULL AND b.search_vector @@ q.query
+		ORDER BY rank DESC, b.id
+		LIMIT $2 OFFSET $3`
+
+	rows, err := q.db.Query(ctx, query, tsq, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search books: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*api.BookSearchResult
+	for rows.Next() {
+		var book api.Book
+		var result api.BookSearchResult
+		err := rows.Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description, &book.Version, &book.TotalCopies, &book.AvailableCopies, &result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		result.Book = &book
+		result.Snippet = highlight(result.Snippet)
+		results = append(results, &result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", err)
+	}
+
+	return &api.SearchBooksResponse{
+		Results: results,
+		PageInfo: &api.PageInfo{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
 	}, nil
 }
 
 func (q *bookQuery) CreateBook(ctx context.Context, tx pgx.Tx, req *api.CreateBookRequest) (*api.CreateBookResponse, error) {
 	if req == nil || req.Book == nil {
-		return nil, errors.New("book cannot be empty")
+		return nil, errs.New(errs.InvalidArgument, "book cannot be empty")
 	}
-	query := `INSERT INTO books (id, title, author, category_id, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, title, author, category_id, description`
+	query := `INSERT INTO books (id, title, author, category_id, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING ` + bookRowColumns
 
 	createdAt := req.Book.CreatedAt.AsTime()
 	updatedAt := req.Book.UpdatedAt.AsTime()
 
 	var createdBook api.Book
 
-	err := tx.QueryRow(ctx, query, req.Book.Id, req.Book.Title, req.Book.Author, req.Book.CategoryId, req.Book.Description, createdAt, updatedAt).Scan(&createdBook.Id, &createdBook.Title, &createdBook.Author, &createdBook.CategoryId, &createdBook.Description)
+	err := tx.QueryRow(ctx, query, req.Book.Id, req.Book.Title, req.Book.Author, req.Book.CategoryId, req.Book.Description, createdAt, updatedAt).Scan(&createdBook.Id, &createdBook.Title, &createdBook.Author, &createdBook.CategoryId, &createdBook.Description, &createdBook.Version)
 	if err != nil {
-		return nil, err
+		return nil, errs.DB(err)
 	}
 
 	return &api.CreateBookResponse{
@@ -98,27 +309,48 @@ func (q *bookQuery) CreateBook(ctx context.Context, tx pgx.Tx, req *api.CreateBo
 
 func (q *bookQuery) UpdateBook(ctx context.Context, tx pgx.Tx, req *api.UpdateBookRequest) (*api.UpdateBookResponse, error) {
 	if req == nil || req.Book == nil {
-		return nil, errors.New("book cannot be empty")
+		return nil, errs.New(errs.InvalidArgument, "book cannot be empty")
+	}
+	if len(req.UpdateMask.GetPaths()) == 0 {
+		return nil, errs.New(errs.InvalidArgument, "update mask cannot be empty")
+	}
+
+	// Only the columns named by the update mask are written.
+	columns := map[string]any{
+		"title":       req.Book.Title,
+		"author":      req.Book.Author,
+		"category_id": req.Book.CategoryId,
+		"description": req.Book.Description,
 	}
-	query := `UPDATE books 
-		SET 
-			title = COALESCE($2, title), 
-			author = COALESCE($3, author), 
-			category_id = COALESCE($4, category_id), 
-			description = COALESCE($5, description), 
-			updated_at = $6
-		WHERE id = $1 AND deleted_at IS NULL RETURNING id, title, author, category_id, description`
 
 	updatedAt := time.Now()
 	if req.Book.UpdatedAt != nil {
 		updatedAt = req.Book.UpdatedAt.AsTime()
 	}
 
+	args := []any{req.Book.Id, updatedAt, req.ExpectedVersion}
+	sets := []string{"updated_at = $2", "version = version + 1"}
+	for _, path := range req.UpdateMask.Paths {
+		value, ok := columns[path]
+		if !ok {
+			return nil, errs.Newf(errs.InvalidArgument, "invalid update mask: field %q cannot be updated", path)
+		}
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", path, len(args)))
+	}
+
+	query := fmt.Sprintf(`UPDATE books SET %s
+		WHERE id = $1 AND deleted_at IS NULL AND version = $3
+		RETURNING %s`, strings.Join(sets, ", "), bookRowColumns)
+
 	var updatedBook api.Book
 
-	err := tx.QueryRow(ctx, query, req.Book.Id, req.Book.Title, req.Book.Author, req.Book.CategoryId, req.Book.Description, updatedAt).Scan(&updatedBook.Id, &updatedBook.Title, &updatedBook.Author, &updatedBook.CategoryId, &updatedBook.Description)
+	err := tx.QueryRow(ctx, query, args...).Scan(&updatedBook.Id, &updatedBook.Title, &updatedBook.Author, &updatedBook.CategoryId, &updatedBook.Description, &updatedBook.Version)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, staleBook(ctx, tx, req.Book.Id, req.ExpectedVersion)
+		}
+		return nil, errs.DB(err)
 	}
 
 	return &api.UpdateBookResponse{
@@ -126,22 +358,351 @@ func (q *bookQuery) UpdateBook(ctx context.Context, tx pgx.Tx, req *api.UpdateBo
 	}, nil
 }
 
+// staleBook explains why a versioned write to a book matched no row: the
+// book is gone, or it has changed since the caller read it.
+func staleBook(ctx context.Context, tx pgx.Tx, id string, expected int64) error {
+	var version int64
+	err := tx.QueryRow(ctx, `SELECT version FROM books WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.Newf(errs.NotFound, "book with ID %s not found", id)
+		}
+		return errs.DB(err)
+	}
+	return errs.Newf(errs.FailedPrecondition, "book with ID %s has been modified: version is %d, not %d", id, version, expected)
+}
+
 func (q *bookQuery) DeleteBook(ctx context.Context, tx pgx.Tx, req *api.DeleteBookRequest) (*api.DeleteBookResponse, error) {
 	if req.BookId == "" {
-		return nil, errors.New("book ID cannot be empty")
+		return nil, errs.New(errs.InvalidArgument, "book ID cannot be empty")
 	}
 
-	query := `UPDATE books SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`
+	query := `UPDATE books SET deleted_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND version = $3`
 
-	_, err := tx.Exec(ctx, query, req.BookId, time.Now())
+	tag, err := tx.Exec(ctx, query, req.BookId, time.Now(), req.ExpectedVersion)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("book with ID %s not found", req.BookId)
-		}
-		return nil, err
+		return nil, errs.DB(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, staleBook(ctx, tx, req.BookId, req.ExpectedVersion)
 	}
 
 	return &api.DeleteBookResponse{
 		Success: true,
 	}, nil
 }
+
+const deletedBookColumns = `id, title, author, category_id, description, created_at, updated_at, deleted_at`
+
+func scanDeletedBook(row pgx.Row) (*api.Book, error) {
+	var book api.Book
+	var createdAt, updatedAt, deletedAt time.Time
+	err := row.Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description, &createdAt, &updatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	book.CreatedAt = timestamppb.New(createdAt)
+	book.UpdatedAt = timestamppb.New(updatedAt)
+	book.DeletedAt = timestamppb.New(deletedAt)
+	return &book, nil
+}
+
+func (q *bookQuery) ListDeletedBooks(ctx context.Context, req *api.ListDeletedBooksRequest) (*api.ListDeletedBooksResponse, error) {
+	limit, offset, err := pagination.Resolve(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := q.db.QueryRow(ctx, `SELECT COUNT(*) FROM books WHERE deleted_at IS NOT NULL`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count deleted books: %w", err)
+	}
+
+	query := `SELECT ` + deletedBookColumns + ` FROM books
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+		LIMIT $1 OFFSET $2`
+
+	rows, err := q.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted books: %w", err)
+	}
+	defer rows.Close()
+
+	var books []*api.Book
+	for rows.Next() {
+		book, err := scanDeletedBook(rows)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read deleted books: %w", err)
+	}
+
+	return &api.ListDeletedBooksResponse{
+		Books: books,
+		PageInfo: &api.PageInfo{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
+	}, nil
+}
+
+func (q *bookQuery) GetDeletedBook(ctx context.Context, id string) (*api.Book, error) {
+	if id == "" {
+		return nil, errs.New(errs.InvalidArgument, "book ID cannot be empty")
+	}
+
+	book, err := scanDeletedBook(q.db.QueryRow(ctx, `SELECT `+deletedBookColumns+` FROM books WHERE id = $1 AND deleted_at IS NOT NULL`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "deleted book with ID %s not found", id)
+		}
+		return nil, errs.DB(err)
+	}
+	return book, nil
+}
+
+func (q *bookQuery) RestoreBook(ctx context.Context, tx pgx.Tx, req *api.RestoreBookRequest) (*api.RestoreBookResponse, error) {
+	if req.BookId == "" {
+		return nil, errs.New(errs.InvalidArgument, "book ID cannot be empty")
+	}
+
+	query := `UPDATE books SET deleted_at = NULL, updated_at = $2, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + bookRowColumns
+
+	var book api.Book
+	err := tx.QueryRow(ctx, query, req.BookId, time.Now()).Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description, &book.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "deleted book with ID %s not found", req.BookId)
+		}
+		return nil, errs.DB(err)
+	}
+
+	return &api.RestoreBookResponse{
+		Book: &book,
+	}, nil
+}
+
+// settledLoan matches loans aliased l that are over: returned, and either on
+// time or with their fines closed.
+const settledLoan = `l.returned_at IS NOT NULL AND (l.returned_at <= l.due_at OR l.fines_closed_at IS NOT NULL)`
+
+// PurgeResult counts what a purge removed, and what it had to keep.
+type PurgeResult struct {
+	Books int64
+	// Loans is how many settled loans of the purged books were kept as
+	// history.
+	Loans int64
+	// Held is how many books past the cutoff stayed in the trash because a
+	// loan of theirs is not settled yet.
+	Held int64
+}
+
+// PurgeBooks permanently removes books deleted before the cutoff, together
+// with their copies and holds. Their settled loans are kept as history, with
+// the book's title and the copy's barcode in place of the IDs. Books with a
+// loan still open, or still accruing fines, stay in the trash until it is
+// settled.
+func (q *bookQuery) PurgeBooks(ctx context.Context, tx pgx.Tx, before time.Time) (PurgeResult, error) {
+	// A single statement so a book restored mid-purge keeps its copies.
+	query := `WITH purged AS (
+			SELECT id, title FROM books b
+			WHERE b.deleted_at < $1
+				AND NOT EXISTS (SELECT 1 FROM loans l WHERE l.book_id = b.id AND NOT (` + settledLoan + `))
+			FOR UPDATE
+		), detached_loans AS (
+			UPDATE loans l SET book_id = NULL, copy_id = NULL, book_title = p.title, copy_barcode = c.barcode
+			FROM purged p, book_copies c
+			WHERE l.book_id = p.id AND c.id = l.copy_id
+			RETURNING l.id
+		), purged_holds AS (
+			DELETE FROM holds WHERE book_id IN (SELECT id FROM purged)
+		), purged_copies AS (
+			DELETE FROM book_copies WHERE book_id IN (SELECT id FROM purged)
+		), purged_books AS (
+			DELETE FROM books WHERE id IN (SELECT id FROM purged) RETURNING id
+		)
+		SELECT (SELECT COUNT(*) FROM purged_books), (SELECT COUNT(*) FROM detached_loans)`
+
+	var res PurgeResult
+	if err := tx.QueryRow(ctx, query, before).Scan(&res.Books, &res.Loans); err != nil {
+		return PurgeResult{}, fmt.Errorf("failed to purge books: %w", err)
+	}
+
+	held := `SELECT COUNT(*) FROM books b
+		WHERE b.deleted_at < $1
+			AND EXISTS (SELECT 1 FROM loans l WHERE l.book_id = b.id AND NOT (` + settledLoan + `))`
+	if err := tx.QueryRow(ctx, held, before).Scan(&res.Held); err != nil {
+		return PurgeResult{}, fmt.Errorf("failed to count books held in the trash: %w", err)
+	}
+	return res, nil
+}
+
+func (q *bookQuery) CountBooksByCategory(ctx context.Context, categoryID string) (int64, error) {
+	if categoryID == "" {
+		return 0, errs.New(errs.InvalidArgument, "category ID cannot be empty")
+	}
+
+	var count int64
+	err := q.db.QueryRow(ctx, `SELECT COUNT(*) FROM books WHERE category_id = $1 AND deleted_at IS NULL`, categoryID).Scan(&count)
+	if err != nil {
+		return 0, errs.DB(err)
+	}
+	return count, nil
+}
+
+// ReassignCategory moves every live book in fromID to toID and returns the
+// moved books.
+func (q *bookQuery) ReassignCategory(ctx context.Context, tx pgx.Tx, fromID, toID string) ([]*api.Book, error) {
+	if fromID == "" || toID == "" {
+		return nil, errs.New(errs.InvalidArgument, "category IDs cannot be empty")
+	}
+
+	query := `UPDATE books SET category_id = $2, updated_at = $3, version = version + 1 WHERE category_id = $1 AND deleted_at IS NULL
+		RETURNING ` + bookRowColumns
+
+	rows, err := tx.Query(ctx, query, fromID, toID, time.Now())
+	if err != nil {
+		return nil, errs.DB(err)
+	}
+	return scanBookRows(rows)
+}
+
+func (q *bookQuery) DeleteBooksByCategory(ctx context.Context, tx pgx.Tx, categoryID string) (int64, error) {
+	if categoryID == "" {
+		return 0, errs.New(errs.InvalidArgument, "category ID cannot be empty")
+	}
+
+	query := `UPDATE books SET deleted_at = $2, version = version + 1 WHERE category_id = $1 AND deleted_at IS NULL`
+
+	tag, err := tx.Exec(ctx, query, categoryID, time.Now())
+	if err != nil {
+		return 0, errs.DB(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// lockCategory takes the advisory lock on a category's books until tx ends:
+// exclusive to close or reopen the category, shared to put a book in it. A
+// book written under the shared lock is therefore committed before the
+// category closes, and is dealt with along with the others.
+func lockCategory(ctx context.Context, tx pgx.Tx, categoryID string, exclusive bool) error {
+	lock := "pg_advisory_xact_lock_shared"
+	if exclusive {
+		lock = "pg_advisory_xact_lock"
+	}
+	if _, err := tx.Exec(ctx, `SELECT `+lock+`(hashtext('category:' || $1))`, categoryID); err != nil {
+		return fmt.Errorf("failed to lock category: %w", err)
+	}
+	return nil
+}
+
+// CloseCategory stops books being put in a category until it is reopened.
+// It must be called before tx locks any of the category's books.
+func (q *bookQuery) CloseCategory(ctx context.Context, tx pgx.Tx, categoryID string) error {
+	if categoryID == "" {
+		return errs.New(errs.InvalidArgument, "category ID cannot be empty")
+	}
+	if err := lockCategory(ctx, tx, categoryID, true); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `INSERT INTO closed_categories (category_id) VALUES ($1) ON CONFLICT (category_id) DO NOTHING`, categoryID)
+	return errs.DB(err)
+}
+
+func (q *bookQuery) ReopenCategory(ctx context.Context, tx pgx.Tx, categoryID string) error {
+	if categoryID == "" {
+		return errs.New(errs.InvalidArgument, "category ID cannot be empty")
+	}
+	if err := lockCategory(ctx, tx, categoryID, true); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `DELETE FROM closed_categories WHERE category_id = $1`, categoryID)
+	return errs.DB(err)
+}
+
+// CheckCategoryOpen refuses to put a book in a closed category, and keeps the
+// category from closing until tx ends. It must be called before tx locks any
+// book.
+func (q *bookQuery) CheckCategoryOpen(ctx context.Context, tx pgx.Tx, categoryID string) error {
+	if categoryID == "" {
+		return errs.New(errs.InvalidArgument, "category ID cannot be empty")
+	}
+	if err := lockCategory(ctx, tx, categoryID, false); err != nil {
+		return err
+	}
+
+	// A statement of its own, so it sees a close that committed while
+	// waiting for the lock.
+	var closed bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM closed_categories WHERE category_id = $1)`, categoryID).Scan(&closed)
+	if err != nil {
+		return errs.DB(err)
+	}
+	if closed {
+		return errs.Newf(errs.Conflict, "category with ID %s is deleted", categoryID)
+	}
+	return nil
+}
+
+// bookRowColumns are the stored fields of a book, without stock counts. The
+// audit log diffs books read with these columns.
+const bookRowColumns = `id, title, author, category_id, description, version`
+
+func scanBookRows(rows pgx.Rows) ([]*api.Book, error) {
+	defer rows.Close()
+
+	var books []*api.Book
+	for rows.Next() {
+		var book api.Book
+		if err := rows.Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description, &book.Version); err != nil {
+			return nil, err
+		}
+		books = append(books, &book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.DB(err)
+	}
+	return books, nil
+}
+
+// LockBook reads a live book and locks it until tx ends.
+func (q *bookQuery) LockBook(ctx context.Context, tx pgx.Tx, id string) (*api.Book, error) {
+	if id == "" {
+		return nil, errs.New(errs.InvalidArgument, "book ID cannot be empty")
+	}
+
+	var book api.Book
+	err := tx.QueryRow(ctx, `SELECT `+bookRowColumns+` FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).
+		Scan(&book.Id, &book.Title, &book.Author, &book.CategoryId, &book.Description, &book.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "book with ID %s not found", id)
+		}
+		return nil, errs.DB(err)
+	}
+	return &book, nil
+}
+
+// LockBooksByCategory reads the live books in a category and locks them
+// until tx ends.
+func (q *bookQuery) LockBooksByCategory(ctx context.Context, tx pgx.Tx, categoryID string) ([]*api.Book, error) {
+	if categoryID == "" {
+		return nil, errs.New(errs.InvalidArgument, "category ID cannot be empty")
+	}
+
+	rows, err := tx.Query(ctx, `SELECT `+bookRowColumns+` FROM books WHERE category_id = $1 AND deleted_at IS NULL ORDER BY id FOR UPDATE`, categoryID)
+	if err != nil {
+		return nil, errs.DB(err)
+	}
+	return scanBookRows(rows)
+}
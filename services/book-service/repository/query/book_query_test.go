@@ -0,0 +1,93 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestHighlightEscapesBookText(t *testing.T) {
+	snippet := `<img src=x onerror="alert(1)"> ` + hitStart + `Dune` + hitStop + ` & sons`
+	want := `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>Dune</mark> &amp; sons`
+	if got := highlight(snippet); got != want {
+		t.Errorf("highlight = %q, want %q", got, want)
+	}
+}
+
+// A book added while its category is being closed is committed first and
+// then found by the close, and none can be added afterwards.
+func TestCloseCategoryWaitsForBookWrites(t *testing.T) {
+	pool := openTestDB(t)
+	books := NewBookQuery(pool)
+	categoryID := uuid.New().String()
+	ctx := context.Background()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := books.CheckCategoryOpen(ctx, tx, categoryID); err != nil {
+		t.Fatal(err)
+	}
+	now := timestamppb.Now()
+	_, err = books.CreateBook(ctx, tx, &api.CreateBookRequest{Book: &api.Book{
+		Id: uuid.New().String(), Title: "Dune", Author: "Frank Herbert", CategoryId: categoryID, CreatedAt: now, UpdatedAt: now,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(chan []*api.Book, 1)
+	closed := make(chan error, 1)
+	go func() {
+		closed <- inTx(ctx, pool, func(tx pgx.Tx) error {
+			if err := books.CloseCategory(ctx, tx, categoryID); err != nil {
+				return err
+			}
+			inCategory, err := books.LockBooksByCategory(ctx, tx, categoryID)
+			found <- inCategory
+			return err
+		})
+	}()
+
+	select {
+	case err := <-closed:
+		t.Fatalf("close finished before the book write ended (err: %v)", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if got := <-found; len(got) != 1 {
+		t.Errorf("close found %d books in the category, want 1", len(got))
+	}
+
+	err = inTx(ctx, pool, func(tx pgx.Tx) error {
+		return books.CheckCategoryOpen(ctx, tx, categoryID)
+	})
+	if !errs.Is(err, errs.Conflict) {
+		t.Errorf("CheckCategoryOpen after close = %v, want conflict", err)
+	}
+
+	err = inTx(ctx, pool, func(tx pgx.Tx) error {
+		if err := books.ReopenCategory(ctx, tx, categoryID); err != nil {
+			return err
+		}
+		return books.CheckCategoryOpen(ctx, tx, categoryID)
+	})
+	if err != nil {
+		t.Errorf("CheckCategoryOpen after reopen = %v, want nil", err)
+	}
+}
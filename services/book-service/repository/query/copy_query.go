@@ -0,0 +1,224 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	CopyAvailable = "available"
+	CopyOnLoan    = "on_loan"
+	CopyOnHold    = "on_hold"
+	CopyRetired   = "retired"
+)
+
+type CopyQuery interface {
+	ListCopies(ctx context.Context, req *api.ListCopiesRequest) (*api.ListCopiesResponse, error)
+	AddCopy(ctx context.Context, tx pgx.Tx, req *api.AddCopyRequest) (*api.AddCopyResponse, error)
+	RetireCopy(ctx context.Context, tx pgx.Tx, req *api.RetireCopyRequest) (*api.RetireCopyResponse, error)
+	RelocateCopy(ctx context.Context, tx pgx.Tx, req *api.RelocateCopyRequest) (*api.RelocateCopyResponse, error)
+}
+
+type copyQuery struct {
+	db    *pgxpool.Pool
+	rules LoanRules
+}
+
+func NewCopyQuery(db *pgxpool.Pool, rules LoanRules) *copyQuery {
+	return &copyQuery{
+		db:    db,
+		rules: rules,
+	}
+}
+
+const copyColumns = `id, book_id, barcode, condition, location, status, created_at, updated_at, retired_at`
+
+func scanCopy(row pgx.Row) (*api.BookCopy, error) {
+	var c api.BookCopy
+	var createdAt, updatedAt time.Time
+	var retiredAt *time.Time
+
+	err := row.Scan(&c.Id, &c.BookId, &c.Barcode, &c.Condition, &c.Location, &c.Status, &createdAt, &updatedAt, &retiredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	c.CreatedAt = timestamppb.New(createdAt)
+	c.UpdatedAt = timestamppb.New(updatedAt)
+	if retiredAt != nil {
+		c.RetiredAt = timestamppb.New(*retiredAt)
+	}
+
+	return &c, nil
+}
+
+func (q *copyQuery) ListCopies(ctx context.Context, req *api.ListCopiesRequest) (*api.ListCopiesResponse, error) {
+	if req == nil || req.BookId == "" {
+		return nil, errs.New(errs.InvalidArgument, "book ID cannot be empty")
+	}
+
+	query := `SELECT ` + copyColumns + ` FROM book_copies WHERE book_id = $1`
+	if !req.IncludeRetired {
+		query += ` AND status <> 'retired'`
+	}
+	query += ` ORDER BY created_at, id`
+
+	rows, err := q.db.Query(ctx, query, req.BookId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query copies: %w", err)
+	}
+	defer rows.Close()
+
+	var copies []*api.BookCopy
+	for rows.Next() {
+		c, err := scanCopy(rows)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read copies: %w", err)
+	}
+
+	return &api.ListCopiesResponse{
+		Copies: copies,
+	}, nil
+}
+
+func (q *copyQuery) AddCopy(ctx context.Context, tx pgx.Tx, req *api.AddCopyRequest) (*api.AddCopyResponse, error) {
+	if req == nil || req.Copy == nil {
+		return nil, errs.New(errs.InvalidArgument, "copy cannot be empty")
+	}
+
+	// Lock the book row so a concurrent delete cannot orphan the new copy,
+	// and so the copy joins the hold queue like a returned one (see
+	// lockHoldQueue).
+	var bookID string
+	err := tx.QueryRow(ctx, `SELECT id FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, req.Copy.BookId).Scan(&bookID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "book with ID %s not found", req.Copy.BookId)
+		}
+		return nil, errs.DB(err)
+	}
+
+	query := `INSERT INTO book_copies (id, book_id, barcode, condition, location, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING ` + copyColumns
+
+	c, err := scanCopy(tx.QueryRow(ctx, query, req.Copy.Id, bookID, req.Copy.Barcode, req.Copy.Condition, req.Copy.Location, CopyAvailable, req.Copy.CreatedAt.AsTime()))
+	if err != nil {
+		return nil, errs.DB(err)
+	}
+
+	// A new copy serves the hold queue before it reaches the shelf.
+	if c.Status, err = releaseCopy(ctx, tx, c.Id, c.BookId, q.rules); err != nil {
+		return nil, err
+	}
+
+	return &api.AddCopyResponse{
+		Copy: c,
+	}, nil
+}
+
+// lockCopy fetches a copy for update so status changes are serialised.
+func lockCopy(ctx context.Context, tx pgx.Tx, id string) (*api.BookCopy, error) {
+	c, err := scanCopy(tx.QueryRow(ctx, `SELECT `+copyColumns+` FROM book_copies WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "copy with ID %s not found", id)
+		}
+		return nil, errs.DB(err)
+	}
+	return c, nil
+}
+
+// copyBookID returns the book a copy belongs to, without locking the copy.
+func copyBookID(ctx context.Context, tx pgx.Tx, id string) (string, error) {
+	var bookID string
+	err := tx.QueryRow(ctx, `SELECT book_id FROM book_copies WHERE id = $1`, id).Scan(&bookID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errs.Newf(errs.NotFound, "copy with ID %s not found", id)
+		}
+		return "", errs.DB(err)
+	}
+	return bookID, nil
+}
+
+var (
+	ErrCopyRetired = errs.New(errs.Conflict, "copy is retired")
+	ErrCopyOnLoan  = errs.New(errs.Conflict, "copy is on loan")
+	ErrCopyOnHold  = errs.New(errs.Conflict, "copy is held for a member")
+)
+
+func (q *copyQuery) RetireCopy(ctx context.Context, tx pgx.Tx, req *api.RetireCopyRequest) (*api.RetireCopyResponse, error) {
+	if req == nil || req.CopyId == "" {
+		return nil, errs.New(errs.InvalidArgument, "copy ID cannot be empty")
+	}
+
+	current, err := lockCopy(ctx, tx, req.CopyId)
+	if err != nil {
+		return nil, err
+	}
+	switch current.Status {
+	case CopyRetired:
+		return nil, ErrCopyRetired
+	case CopyOnLoan:
+		return nil, ErrCopyOnLoan
+	case CopyOnHold:
+		return nil, ErrCopyOnHold
+	}
+
+	query := `UPDATE book_copies
+		SET status = $2, retired_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + copyColumns
+
+	c, err := scanCopy(tx.QueryRow(ctx, query, req.CopyId, CopyRetired))
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.RetireCopyResponse{
+		Copy: c,
+	}, nil
+}
+
+func (q *copyQuery) RelocateCopy(ctx context.Context, tx pgx.Tx, req *api.RelocateCopyRequest) (*api.RelocateCopyResponse, error) {
+	if req == nil || req.CopyId == "" {
+		return nil, errs.New(errs.InvalidArgument, "copy ID cannot be empty")
+	}
+
+	current, err := lockCopy(ctx, tx, req.CopyId)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == CopyRetired {
+		return nil, ErrCopyRetired
+	}
+
+	query := `UPDATE book_copies
+		SET location = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + copyColumns
+
+	c, err := scanCopy(tx.QueryRow(ctx, query, req.CopyId, req.Location))
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.RelocateCopyResponse{
+		Copy: c,
+	}, nil
+}
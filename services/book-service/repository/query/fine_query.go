@@ -0,0 +1,248 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/pagination"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	LedgerFine    = "fine"
+	LedgerWaiver  = "waiver"
+	LedgerPayment = "payment"
+)
+
+var (
+	ErrFinesOutstanding = errs.New(errs.Conflict, "borrower has outstanding fines above the checkout limit")
+	ErrCreditTooLarge   = errs.New(errs.Conflict, "amount is more than the outstanding balance")
+)
+
+// FineCandidate is an overdue loan whose fine may still grow.
+type FineCandidate struct {
+	LoanID     string
+	UserID     string
+	CategoryID string
+	DueAt      time.Time
+	ReturnedAt *time.Time
+}
+
+type FineQuery interface {
+	ListFineCandidates(ctx context.Context) ([]FineCandidate, error)
+	ChargeFine(ctx context.Context, tx pgx.Tx, loan FineCandidate, total int64) (int64, error)
+	GetBalance(ctx context.Context, userID string) (int64, error)
+	ListLedger(ctx context.Context, req *api.ListLedgerRequest) (*api.ListLedgerResponse, error)
+	Credit(ctx context.Context, tx pgx.Tx, entry *api.LedgerEntry) (*api.LedgerEntry, error)
+}
+
+type fineQuery struct {
+	db *pgxpool.Pool
+}
+
+func NewFineQuery(db *pgxpool.Pool) *fineQuery {
+	return &fineQuery{
+		db: db,
+	}
+}
+
+const ledgerColumns = `id, user_id, loan_id, kind, amount_cents, note, created_by, created_at`
+
+func scanLedgerEntry(row pgx.Row) (*api.LedgerEntry, error) {
+	var e api.LedgerEntry
+	var loanID, createdBy *string
+	var createdAt time.Time
+
+	err := row.Scan(&e.Id, &e.UserId, &loanID, &e.Kind, &e.AmountCents, &e.Note, &createdBy, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if loanID != nil {
+		e.LoanId = *loanID
+	}
+	if createdBy != nil {
+		e.CreatedBy = *createdBy
+	}
+	e.CreatedAt = timestamppb.New(createdAt)
+
+	return &e, nil
+}
+
+// queryRower is satisfied by both the pool and a transaction.
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// balance is the sum of a member's ledger; positive means they owe money.
+func balance(ctx context.Context, db queryRower, userID string) (int64, error) {
+	var cents int64
+	err := db.QueryRow(ctx, `SELECT COALESCE(SUM(amount_cents), 0) FROM fine_ledger WHERE user_id = $1`, userID).Scan(&cents)
+	return cents, err
+}
+
+func (q *fineQuery) ListFineCandidates(ctx context.Context) ([]FineCandidate, error) {
+	query := `SELECT l.id, l.user_id, b.category_id, l.due_at, l.returned_at
+		FROM loans l JOIN books b ON b.id = l.book_id
+		WHERE l.fines_closed_at IS NULL
+			AND l.due_at < CURRENT_TIMESTAMP
+			AND (l.returned_at IS NULL OR l.returned_at > l.due_at)`
+
+	rows, err := q.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query overdue loans: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []FineCandidate
+	for rows.Next() {
+		var c FineCandidate
+		var categoryID *string
+		if err := rows.Scan(&c.LoanID, &c.UserID, &categoryID, &c.DueAt, &c.ReturnedAt); err != nil {
+			return nil, err
+		}
+		if categoryID != nil {
+			c.CategoryID = *categoryID
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
+
+// ChargeFine brings the fines recorded for a loan up to total and returns
+// the amount added. Fines on returned loans are closed afterwards so later
+// runs skip them.
+func (q *fineQuery) ChargeFine(ctx context.Context, tx pgx.Tx, loan FineCandidate, total int64) (int64, error) {
+	var loanID string
+	err := tx.QueryRow(ctx, `SELECT id FROM loans WHERE id = $1 AND fines_closed_at IS NULL FOR UPDATE`, loan.LoanID).Scan(&loanID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Closed by a concurrent run.
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var charged int64
+	err = tx.QueryRow(ctx, `SELECT COALESCE(SUM(amount_cents), 0) FROM fine_ledger WHERE loan_id = $1 AND kind = $2`, loanID, LedgerFine).Scan(&charged)
+	if err != nil {
+		return 0, err
+	}
+
+	delta := total - charged
+	if delta > 0 {
+		_, err = tx.Exec(ctx, `INSERT INTO fine_ledger (id, user_id, loan_id, kind, amount_cents, note) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.NewString(), loan.UserID, loanID, LedgerFine, delta, "Late fee")
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if loan.ReturnedAt != nil {
+		if _, err := tx.Exec(ctx, `UPDATE loans SET fines_closed_at = CURRENT_TIMESTAMP WHERE id = $1`, loanID); err != nil {
+			return 0, err
+		}
+	}
+
+	return max(delta, 0), nil
+}
+
+func (q *fineQuery) GetBalance(ctx context.Context, userID string) (int64, error) {
+	if userID == "" {
+		return 0, errs.New(errs.InvalidArgument, "user ID cannot be empty")
+	}
+	return balance(ctx, q.db, userID)
+}
+
+func (q *fineQuery) ListLedger(ctx context.Context, req *api.ListLedgerRequest) (*api.ListLedgerResponse, error) {
+	if req == nil || req.UserId == "" {
+		return nil, errs.New(errs.InvalidArgument, "user ID cannot be empty")
+	}
+	limit, offset, err := pagination.Resolve(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := q.db.QueryRow(ctx, `SELECT COUNT(*) FROM fine_ledger WHERE user_id = $1`, req.UserId).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count ledger entries: %w", err)
+	}
+
+	cents, err := balance(ctx, q.db, req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read balance: %w", err)
+	}
+
+	rows, err := q.db.Query(ctx, `SELECT `+ledgerColumns+` FROM fine_ledger WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`,
+		req.UserId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*api.LedgerEntry
+	for rows.Next() {
+		e, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %w", err)
+	}
+
+	return &api.ListLedgerResponse{
+		Entries: entries,
+		PageInfo: &api.PageInfo{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
+		BalanceCents: cents,
+	}, nil
+}
+
+// Credit records a waiver or payment. entry.AmountCents is the positive
+// amount credited; it is stored negated and may not exceed what is owed.
+func (q *fineQuery) Credit(ctx context.Context, tx pgx.Tx, entry *api.LedgerEntry) (*api.LedgerEntry, error) {
+	if entry == nil || entry.UserId == "" || entry.AmountCents <= 0 {
+		return nil, errs.New(errs.InvalidArgument, "user ID and a positive amount are required")
+	}
+
+	// Serialise credits per member so two cannot both fit under the balance.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('fines:' || $1))`, entry.UserId); err != nil {
+		return nil, err
+	}
+
+	owed, err := balance(ctx, tx, entry.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if entry.AmountCents > owed {
+		return nil, ErrCreditTooLarge
+	}
+
+	var loanID, createdBy *string
+	if entry.LoanId != "" {
+		loanID = &entry.LoanId
+	}
+	if entry.CreatedBy != "" {
+		createdBy = &entry.CreatedBy
+	}
+
+	query := `INSERT INTO fine_ledger (id, user_id, loan_id, kind, amount_cents, note, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + ledgerColumns
+
+	return scanLedgerEntry(tx.QueryRow(ctx, query, entry.Id, entry.UserId, loanID, entry.Kind, -entry.AmountCents, entry.Note, createdBy, entry.CreatedAt.AsTime()))
+}
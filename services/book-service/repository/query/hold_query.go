@@ -0,0 +1,321 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	HoldWaiting   = "waiting"
+	HoldReady     = "ready"
+	HoldFulfilled = "fulfilled"
+	HoldCancelled = "cancelled"
+	HoldExpired   = "expired"
+)
+
+var (
+	ErrCopiesAvailable = errs.New(errs.Conflict, "a copy is available, check it out instead of placing a hold")
+	ErrHoldExists      = errs.New(errs.Conflict, "member already has an open hold on this book")
+	ErrHoldClosed      = errs.New(errs.Conflict, "hold is no longer open")
+	ErrHoldsWaiting    = errs.New(errs.Conflict, "other members are waiting for this book")
+)
+
+type HoldQuery interface {
+	GetHold(ctx context.Context, req *api.GetHoldRequest) (*api.Hold, error)
+	ListHolds(ctx context.Context, req *api.ListHoldsRequest) (*api.ListHoldsResponse, error)
+	PlaceHold(ctx context.Context, tx pgx.Tx, hold *api.Hold) (*api.Hold, error)
+	CancelHold(ctx context.Context, tx pgx.Tx, req *api.CancelHoldRequest) (*api.Hold, error)
+	ExpireHolds(ctx context.Context, tx pgx.Tx) (int, error)
+}
+
+type holdQuery struct {
+	db    *pgxpool.Pool
+	rules LoanRules
+}
+
+func NewHoldQuery(db *pgxpool.Pool, rules LoanRules) *holdQuery {
+	return &holdQuery{
+		db:    db,
+		rules: rules,
+	}
+}
+
+// holdColumns selects a hold aliased as h, including its queue position.
+const holdColumns = `h.id, h.book_id, h.user_id, h.status, h.copy_id, h.created_at, h.ready_at, h.expires_at,
+	CASE WHEN h.status = 'waiting' THEN (
+		SELECT COUNT(*) FROM holds w
+		WHERE w.book_id = h.book_id AND w.status = 'waiting' AND (w.created_at, w.id) <= (h.created_at, h.id)
+	) ELSE 0 END`
+
+func scanHold(row pgx.Row) (*api.Hold, error) {
+	var h api.Hold
+	var copyID *string
+	var createdAt time.Time
+	var readyAt, expiresAt *time.Time
+
+	err := row.Scan(&h.Id, &h.BookId, &h.UserId, &h.Status, &copyID, &createdAt, &readyAt, &expiresAt, &h.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	if copyID != nil {
+		h.CopyId = *copyID
+	}
+	h.CreatedAt = timestamppb.New(createdAt)
+	if readyAt != nil {
+		h.ReadyAt = timestamppb.New(*readyAt)
+	}
+	if expiresAt != nil {
+		h.ExpiresAt = timestamppb.New(*expiresAt)
+	}
+
+	return &h, nil
+}
+
+func (q *holdQuery) GetHold(ctx context.Context, req *api.GetHoldRequest) (*api.Hold, error) {
+	if req == nil || req.HoldId == "" {
+		return nil, errs.New(errs.InvalidArgument, "hold ID cannot be empty")
+	}
+
+	query := `SELECT ` + holdColumns + ` FROM holds h WHERE h.id = $1 AND ($2 = '' OR h.user_id::text = $2)`
+
+	h, err := scanHold(q.db.QueryRow(ctx, query, req.HoldId, req.UserId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "hold with ID %s not found", req.HoldId)
+		}
+		return nil, errs.DB(err)
+	}
+
+	return h, nil
+}
+
+func (q *holdQuery) ListHolds(ctx context.Context, req *api.ListHoldsRequest) (*api.ListHoldsResponse, error) {
+	where := `WHERE true`
+	args := []any{}
+	if req.UserId != "" {
+		args = append(args, req.UserId)
+		where += fmt.Sprintf(` AND h.user_id = $%d`, len(args))
+	}
+	if req.BookId != "" {
+		args = append(args, req.BookId)
+		where += fmt.Sprintf(` AND h.book_id = $%d`, len(args))
+	}
+	if req.ActiveOnly {
+		where += ` AND h.status IN ('waiting', 'ready')`
+	}
+
+	rows, err := q.db.Query(ctx, `SELECT `+holdColumns+` FROM holds h `+where+` ORDER BY h.created_at, h.id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holds: %w", err)
+	}
+	defer rows.Close()
+
+	var holds []*api.Hold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read holds: %w", err)
+	}
+
+	return &api.ListHoldsResponse{
+		Holds: holds,
+	}, nil
+}
+
+func (q *holdQuery) PlaceHold(ctx context.Context, tx pgx.Tx, hold *api.Hold) (*api.Hold, error) {
+	if hold == nil || hold.BookId == "" || hold.UserId == "" {
+		return nil, errs.New(errs.InvalidArgument, "book ID and user ID cannot be empty")
+	}
+
+	// Lock the book's hold queue so a copy cannot be returned between the
+	// availability check and joining the queue.
+	var bookID string
+	err := tx.QueryRow(ctx, `SELECT id FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, hold.BookId).Scan(&bookID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "book with ID %s not found", hold.BookId)
+		}
+		return nil, errs.DB(err)
+	}
+
+	var available, open bool
+	err = tx.QueryRow(ctx, `SELECT
+		EXISTS (SELECT 1 FROM book_copies WHERE book_id = $1 AND status = 'available'),
+		EXISTS (SELECT 1 FROM holds WHERE book_id = $1 AND user_id = $2 AND status IN ('waiting', 'ready'))`,
+		bookID, hold.UserId).Scan(&available, &open)
+	if err != nil {
+		return nil, err
+	}
+	if available {
+		return nil, ErrCopiesAvailable
+	}
+	if open {
+		return nil, ErrHoldExists
+	}
+
+	var id string
+	err = tx.QueryRow(ctx, `INSERT INTO holds (id, book_id, user_id, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5) RETURNING id`,
+		hold.Id, bookID, hold.UserId, HoldWaiting, hold.CreatedAt.AsTime()).Scan(&id)
+	if err != nil {
+		return nil, errs.DB(err)
+	}
+
+	return scanHold(tx.QueryRow(ctx, `SELECT `+holdColumns+` FROM holds h WHERE h.id = $1`, id))
+}
+
+func (q *holdQuery) CancelHold(ctx context.Context, tx pgx.Tx, req *api.CancelHoldRequest) (*api.Hold, error) {
+	if req == nil || req.HoldId == "" {
+		return nil, errs.New(errs.InvalidArgument, "hold ID cannot be empty")
+	}
+
+	var bookID string
+	err := tx.QueryRow(ctx, `SELECT book_id FROM holds WHERE id = $1 AND ($2 = '' OR user_id::text = $2)`,
+		req.HoldId, req.UserId).Scan(&bookID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "hold with ID %s not found", req.HoldId)
+		}
+		return nil, errs.DB(err)
+	}
+	if err := lockHoldQueue(ctx, tx, bookID); err != nil {
+		return nil, err
+	}
+
+	var status string
+	var copyID *string
+	err = tx.QueryRow(ctx, `SELECT status, copy_id FROM holds WHERE id = $1 FOR UPDATE`, req.HoldId).Scan(&status, &copyID)
+	if err != nil {
+		return nil, errs.DB(err)
+	}
+	if status != HoldWaiting && status != HoldReady {
+		return nil, ErrHoldClosed
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE holds SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, req.HoldId, HoldCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	// A cancelled ready hold gives its copy to the next member in line.
+	if status == HoldReady && copyID != nil {
+		if _, err := releaseCopy(ctx, tx, *copyID, bookID, q.rules); err != nil {
+			return nil, err
+		}
+	}
+
+	return scanHold(tx.QueryRow(ctx, `SELECT `+holdColumns+` FROM holds h WHERE h.id = $1`, req.HoldId))
+}
+
+// ExpireHolds closes ready holds whose pickup deadline has passed and passes
+// their copies on, returning how many holds expired. Books are locked in ID
+// order, so concurrent runs queue behind each other instead of deadlocking.
+func (q *holdQuery) ExpireHolds(ctx context.Context, tx pgx.Tx) (int, error) {
+	rows, err := tx.Query(ctx, `SELECT DISTINCT book_id FROM holds
+		WHERE status = 'ready' AND expires_at < CURRENT_TIMESTAMP
+		ORDER BY book_id`)
+	if err != nil {
+		return 0, err
+	}
+	bookIDs, err := scanIDs(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, bookID := range bookIDs {
+		if err := lockHoldQueue(ctx, tx, bookID); err != nil {
+			return 0, err
+		}
+
+		rows, err := tx.Query(ctx, `UPDATE holds SET status = $1, updated_at = CURRENT_TIMESTAMP
+			WHERE book_id = $2 AND status = 'ready' AND expires_at < CURRENT_TIMESTAMP
+			RETURNING copy_id`, HoldExpired, bookID)
+		if err != nil {
+			return 0, err
+		}
+		copyIDs, err := scanIDs(rows)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, copyID := range copyIDs {
+			if _, err := releaseCopy(ctx, tx, copyID, bookID, q.rules); err != nil {
+				return 0, err
+			}
+		}
+		expired += len(copyIDs)
+	}
+
+	return expired, nil
+}
+
+// scanIDs reads a single text column from every row and closes rows.
+func scanIDs(rows pgx.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// lockHoldQueue locks a book, trashed or not, for a change to its hold queue.
+// PlaceHold and every caller of releaseCopy take this lock before any copy or
+// hold row of the book, so a copy cannot reach the shelf while a member joins
+// the queue, and waiting members are served strictly in order.
+func lockHoldQueue(ctx context.Context, tx pgx.Tx, bookID string) error {
+	_, err := tx.Exec(ctx, `SELECT 1 FROM books WHERE id = $1 FOR UPDATE`, bookID)
+	return err
+}
+
+// releaseCopy makes a copy that just came back to the shelf ready for the
+// first member waiting on its book, or available when nobody is waiting. It
+// returns the copy's new status. The caller must hold lockHoldQueue for the
+// book.
+func releaseCopy(ctx context.Context, tx pgx.Tx, copyID, bookID string, rules LoanRules) (string, error) {
+	now := time.Now()
+
+	tag, err := tx.Exec(ctx, `UPDATE holds SET status = $1, copy_id = $2, ready_at = $3, expires_at = $4, updated_at = $3
+		WHERE id = (
+			SELECT id FROM holds
+			WHERE book_id = $5 AND status = 'waiting'
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE
+		)`, HoldReady, copyID, now, rules.PickupDeadline(now), bookID)
+	if err != nil {
+		return "", err
+	}
+
+	status := CopyAvailable
+	if tag.RowsAffected() > 0 {
+		status = CopyOnHold
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE book_copies SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, copyID, status)
+	if err != nil {
+		return "", err
+	}
+
+	return status, nil
+}
@@ -0,0 +1,177 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/migrations"
+	"github.com/daffaromero/gobook/services/common/servicetest"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var testRules = LoanRules{PeriodDays: 14, MaxRenewals: 2, MaxActive: 5, HoldPickupDays: 3, FineBlockCents: 1000}
+
+func openTestDB(t *testing.T) *pgxpool.Pool {
+	return servicetest.OpenDB(t, "TEST_BOOKS_DATABASE_URL", migrations.FS)
+}
+
+// inTx runs fn in a transaction of its own and commits it.
+func inTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	return pgx.BeginFunc(ctx, pool, fn)
+}
+
+// holdQueue is a book with one copy out on loan and the queries to act on it.
+type holdQueue struct {
+	pool   *pgxpool.Pool
+	loans  *loanQuery
+	holds  *holdQuery
+	bookID string
+	copyID string
+}
+
+func newHoldQueue(t *testing.T) *holdQueue {
+	t.Helper()
+
+	pool := openTestDB(t)
+	q := &holdQueue{
+		pool:   pool,
+		loans:  NewLoanQuery(pool, testRules),
+		holds:  NewHoldQuery(pool, testRules),
+		bookID: uuid.New().String(),
+		copyID: uuid.New().String(),
+	}
+
+	now := timestamppb.Now()
+	err := inTx(context.Background(), pool, func(tx pgx.Tx) error {
+		ctx := context.Background()
+		_, err := NewBookQuery(pool).CreateBook(ctx, tx, &api.CreateBookRequest{Book: &api.Book{
+			Id: q.bookID, Title: "Dune", Author: "Frank Herbert", CategoryId: uuid.New().String(), CreatedAt: now, UpdatedAt: now,
+		}})
+		if err != nil {
+			return err
+		}
+		_, err = NewCopyQuery(pool, testRules).AddCopy(ctx, tx, &api.AddCopyRequest{Copy: &api.BookCopy{
+			Id: q.copyID, BookId: q.bookID, Barcode: uuid.New().String(), Condition: "good", CreatedAt: now,
+		}})
+		if err != nil {
+			return err
+		}
+		_, err = q.loans.CheckoutCopy(ctx, tx, &api.Loan{Id: uuid.New().String(), CopyId: q.copyID, UserId: uuid.New().String(), CheckedOutAt: now})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func (q *holdQueue) placeHold(ctx context.Context, tx pgx.Tx, at time.Time) (*api.Hold, error) {
+	return q.holds.PlaceHold(ctx, tx, &api.Hold{Id: uuid.New().String(), BookId: q.bookID, UserId: uuid.New().String(), CreatedAt: timestamppb.New(at)})
+}
+
+// returnBlockedBy returns the copy in a transaction of its own while tx is
+// still open, checks that the return waits for tx, and then ends tx with end.
+func (q *holdQueue) returnBlockedBy(t *testing.T, tx pgx.Tx, end func(context.Context) error) {
+	t.Helper()
+
+	ctx := context.Background()
+	returned := make(chan error, 1)
+	go func() {
+		returned <- inTx(ctx, q.pool, func(tx pgx.Tx) error {
+			_, err := q.loans.ReturnCopy(ctx, tx, &api.ReturnCopyRequest{CopyId: q.copyID})
+			return err
+		})
+	}()
+
+	select {
+	case err := <-returned:
+		t.Fatalf("return finished before the concurrent transaction ended (err: %v)", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := end(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-returned; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (q *holdQueue) wantReady(t *testing.T, holdID string) {
+	t.Helper()
+
+	hold, err := q.holds.GetHold(context.Background(), &api.GetHoldRequest{HoldId: holdID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hold.Status != HoldReady || hold.CopyId != q.copyID {
+		t.Errorf("hold is %s with copy %q, want %s with copy %s", hold.Status, hold.CopyId, HoldReady, q.copyID)
+	}
+}
+
+// A copy returned while a member joins the queue must go to that member, not
+// back to the shelf.
+func TestReturnWaitsForPlaceHold(t *testing.T) {
+	q := newHoldQueue(t)
+	ctx := context.Background()
+
+	tx, err := q.pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	hold, err := q.placeHold(ctx, tx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.returnBlockedBy(t, tx, tx.Commit)
+	q.wantReady(t, hold.Id)
+}
+
+// A returned copy goes to the first member in line even while that member's
+// hold is locked by a cancellation that is then abandoned.
+func TestReturnServesQueueInOrder(t *testing.T) {
+	q := newHoldQueue(t)
+	ctx := context.Background()
+
+	var first, second *api.Hold
+	err := inTx(ctx, q.pool, func(tx pgx.Tx) (err error) {
+		now := time.Now()
+		if first, err = q.placeHold(ctx, tx, now); err != nil {
+			return err
+		}
+		second, err = q.placeHold(ctx, tx, now.Add(time.Second))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := q.pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := q.holds.CancelHold(ctx, tx, &api.CancelHoldRequest{HoldId: first.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	q.returnBlockedBy(t, tx, tx.Rollback)
+	q.wantReady(t, first.Id)
+
+	hold, err := q.holds.GetHold(ctx, &api.GetHoldRequest{HoldId: second.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hold.Status != HoldWaiting || hold.Position != 1 {
+		t.Errorf("second hold is %s at position %d, want %s at position 1", hold.Status, hold.Position, HoldWaiting)
+	}
+}
@@ -0,0 +1,311 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/pagination"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrCopyUnavailable = errs.New(errs.Conflict, "copy is not available for loan")
+	ErrLoanLimit       = errs.New(errs.Conflict, "borrower has reached the active loan limit")
+	ErrNoActiveLoan    = errs.New(errs.Conflict, "copy is not on loan")
+	ErrLoanClosed      = errs.New(errs.Conflict, "loan has already been returned")
+	ErrLoanOverdue     = errs.New(errs.Conflict, "overdue loans cannot be renewed")
+	ErrRenewalLimit    = errs.New(errs.Conflict, "loan has reached the renewal limit")
+)
+
+// LoanRules are the lending limits enforced inside the loan transactions.
+type LoanRules struct {
+	PeriodDays     int
+	MaxRenewals    int
+	MaxActive      int
+	HoldPickupDays int
+	FineBlockCents int64
+}
+
+// DueDate is when a loan started or renewed at from must be returned.
+func (r LoanRules) DueDate(from time.Time) time.Time {
+	return from.AddDate(0, 0, r.PeriodDays)
+}
+
+// PickupDeadline is when a hold that became ready at from expires.
+func (r LoanRules) PickupDeadline(from time.Time) time.Time {
+	return from.AddDate(0, 0, r.HoldPickupDays)
+}
+
+type LoanQuery interface {
+	ListLoans(ctx context.Context, req *api.ListLoansRequest) (*api.ListLoansResponse, error)
+	CheckoutCopy(ctx context.Context, tx pgx.Tx, loan *api.Loan) (*api.Loan, error)
+	ReturnCopy(ctx context.Context, tx pgx.Tx, req *api.ReturnCopyRequest) (*api.Loan, error)
+	RenewLoan(ctx context.Context, tx pgx.Tx, req *api.RenewLoanRequest) (*api.Loan, error)
+}
+
+type loanQuery struct {
+	db    *pgxpool.Pool
+	rules LoanRules
+}
+
+func NewLoanQuery(db *pgxpool.Pool, rules LoanRules) *loanQuery {
+	return &loanQuery{
+		db:    db,
+		rules: rules,
+	}
+}
+
+// loanColumns include the title and barcode kept once the book is purged, in
+// place of book_id and copy_id.
+const loanColumns = `id, COALESCE(copy_id::text, ''), COALESCE(book_id::text, ''), user_id, checked_out_at, due_at, returned_at, renewals, COALESCE(book_title, ''), COALESCE(copy_barcode, '')`
+
+func scanLoan(row pgx.Row) (*api.Loan, error) {
+	var l api.Loan
+	var checkedOutAt, dueAt time.Time
+	var returnedAt *time.Time
+
+	err := row.Scan(&l.Id, &l.CopyId, &l.BookId, &l.UserId, &checkedOutAt, &dueAt, &returnedAt, &l.Renewals, &l.BookTitle, &l.CopyBarcode)
+	if err != nil {
+		return nil, err
+	}
+
+	l.CheckedOutAt = timestamppb.New(checkedOutAt)
+	l.DueAt = timestamppb.New(dueAt)
+	if returnedAt != nil {
+		l.ReturnedAt = timestamppb.New(*returnedAt)
+	} else {
+		l.Overdue = time.Now().After(dueAt)
+	}
+
+	return &l, nil
+}
+
+func (q *loanQuery) ListLoans(ctx context.Context, req *api.ListLoansRequest) (*api.ListLoansResponse, error) {
+	limit, offset, err := pagination.Resolve(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	where := `WHERE true`
+	args := []any{}
+	if req.UserId != "" {
+		args = append(args, req.UserId)
+		where += fmt.Sprintf(` AND user_id = $%d`, len(args))
+	}
+	if req.BookId != "" {
+		args = append(args, req.BookId)
+		where += fmt.Sprintf(` AND book_id = $%d`, len(args))
+	}
+	if req.ActiveOnly {
+		where += ` AND returned_at IS NULL`
+	}
+
+	var total int64
+	if err := q.db.QueryRow(ctx, `SELECT COUNT(*) FROM loans `+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count loans: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM loans %s ORDER BY checked_out_at DESC, id DESC LIMIT $%d OFFSET $%d`, loanColumns, where, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loans: %w", err)
+	}
+	defer rows.Close()
+
+	var loans []*api.Loan
+	for rows.Next() {
+		l, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read loans: %w", err)
+	}
+
+	return &api.ListLoansResponse{
+		Loans: loans,
+		PageInfo: &api.PageInfo{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
+	}, nil
+}
+
+// CheckoutCopy lends the copy to the borrower. The copy row is locked for the
+// rest of the transaction, so two checkouts of the same copy serialise and
+// the second one sees it on loan. Copies of a deleted book cannot be lent.
+func (q *loanQuery) CheckoutCopy(ctx context.Context, tx pgx.Tx, loan *api.Loan) (*api.Loan, error) {
+	if loan == nil || loan.CopyId == "" || loan.UserId == "" {
+		return nil, errs.New(errs.InvalidArgument, "copy ID and user ID cannot be empty")
+	}
+
+	// Serialise checkouts per borrower so the active loan limit holds.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, loan.UserId); err != nil {
+		return nil, err
+	}
+
+	var active int
+	err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM loans WHERE user_id = $1 AND returned_at IS NULL`, loan.UserId).Scan(&active)
+	if err != nil {
+		return nil, err
+	}
+	if active >= q.rules.MaxActive {
+		return nil, ErrLoanLimit
+	}
+
+	owed, err := balance(ctx, tx, loan.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if owed > q.rules.FineBlockCents {
+		return nil, ErrFinesOutstanding
+	}
+
+	bookID, err := copyBookID(ctx, tx, loan.CopyId)
+	if err != nil {
+		return nil, err
+	}
+	// Share the book row before locking the copy, like AddCopy, so the book
+	// cannot be deleted while the copy goes out.
+	err = tx.QueryRow(ctx, `SELECT id FROM books WHERE id = $1 AND deleted_at IS NULL FOR SHARE`, bookID).Scan(&bookID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "book with ID %s not found", bookID)
+		}
+		return nil, errs.DB(err)
+	}
+
+	current, err := lockCopy(ctx, tx, loan.CopyId)
+	if err != nil {
+		return nil, err
+	}
+	switch current.Status {
+	case CopyRetired:
+		return nil, ErrCopyRetired
+	case CopyOnLoan:
+		return nil, ErrCopyUnavailable
+	case CopyOnHold:
+		// A held copy can only go to the member it is held for.
+		tag, err := tx.Exec(ctx, `UPDATE holds SET status = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE copy_id = $1 AND user_id = $2 AND status = 'ready'`, current.Id, loan.UserId, HoldFulfilled)
+		if err != nil {
+			return nil, err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil, ErrCopyUnavailable
+		}
+	default:
+		// Taking an available copy also settles the borrower's place in the queue.
+		_, err := tx.Exec(ctx, `UPDATE holds SET status = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE book_id = $1 AND user_id = $2 AND status = 'waiting'`, current.BookId, loan.UserId, HoldFulfilled)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	checkedOutAt := loan.CheckedOutAt.AsTime()
+	query := `INSERT INTO loans (id, copy_id, book_id, user_id, checked_out_at, due_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + loanColumns
+
+	created, err := scanLoan(tx.QueryRow(ctx, query, loan.Id, current.Id, current.BookId, loan.UserId, checkedOutAt, q.rules.DueDate(checkedOutAt)))
+	if err != nil {
+		return nil, errs.DB(err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE book_copies SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, current.Id, CopyOnLoan)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (q *loanQuery) ReturnCopy(ctx context.Context, tx pgx.Tx, req *api.ReturnCopyRequest) (*api.Loan, error) {
+	if req == nil || req.CopyId == "" {
+		return nil, errs.New(errs.InvalidArgument, "copy ID cannot be empty")
+	}
+
+	bookID, err := copyBookID(ctx, tx, req.CopyId)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockHoldQueue(ctx, tx, bookID); err != nil {
+		return nil, err
+	}
+	returnedCopy, err := lockCopy(ctx, tx, req.CopyId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE loans SET returned_at = CURRENT_TIMESTAMP
+		WHERE copy_id = $1 AND returned_at IS NULL
+		RETURNING ` + loanColumns
+
+	returned, err := scanLoan(tx.QueryRow(ctx, query, req.CopyId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoActiveLoan
+		}
+		return nil, err
+	}
+
+	// The returned copy goes to the first member waiting for the book.
+	if _, err := releaseCopy(ctx, tx, returnedCopy.Id, returnedCopy.BookId, q.rules); err != nil {
+		return nil, err
+	}
+
+	return returned, nil
+}
+
+func (q *loanQuery) RenewLoan(ctx context.Context, tx pgx.Tx, req *api.RenewLoanRequest) (*api.Loan, error) {
+	if req == nil || req.LoanId == "" {
+		return nil, errs.New(errs.InvalidArgument, "loan ID cannot be empty")
+	}
+
+	query := `SELECT ` + loanColumns + ` FROM loans WHERE id = $1 AND ($2 = '' OR user_id::text = $2) FOR UPDATE`
+
+	current, err := scanLoan(tx.QueryRow(ctx, query, req.LoanId, req.UserId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "loan with ID %s not found", req.LoanId)
+		}
+		return nil, errs.DB(err)
+	}
+
+	switch {
+	case current.ReturnedAt != nil:
+		return nil, ErrLoanClosed
+	case current.Overdue:
+		return nil, ErrLoanOverdue
+	case int(current.Renewals) >= q.rules.MaxRenewals:
+		return nil, ErrRenewalLimit
+	}
+
+	var waiting bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM holds WHERE book_id = $1 AND status = 'waiting')`, current.BookId).Scan(&waiting)
+	if err != nil {
+		return nil, err
+	}
+	if waiting {
+		return nil, ErrHoldsWaiting
+	}
+
+	query = `UPDATE loans SET due_at = $2, renewals = renewals + 1
+		WHERE id = $1
+		RETURNING ` + loanColumns
+
+	return scanLoan(tx.QueryRow(ctx, query, req.LoanId, q.rules.DueDate(current.DueAt.AsTime())))
+}
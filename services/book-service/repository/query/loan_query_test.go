@@ -0,0 +1,49 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// A copy left behind by a deleted book stays on the shelf.
+func TestCheckoutRefusesDeletedBook(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	books := NewBookQuery(pool)
+	bookID, copyID := uuid.New().String(), uuid.New().String()
+
+	now := timestamppb.Now()
+	err := inTx(ctx, pool, func(tx pgx.Tx) error {
+		res, err := books.CreateBook(ctx, tx, &api.CreateBookRequest{Book: &api.Book{
+			Id: bookID, Title: "Dune", Author: "Frank Herbert", CategoryId: uuid.New().String(), CreatedAt: now, UpdatedAt: now,
+		}})
+		if err != nil {
+			return err
+		}
+		_, err = NewCopyQuery(pool, testRules).AddCopy(ctx, tx, &api.AddCopyRequest{Copy: &api.BookCopy{
+			Id: copyID, BookId: bookID, Barcode: uuid.New().String(), Condition: "good", CreatedAt: now,
+		}})
+		if err != nil {
+			return err
+		}
+		_, err = books.DeleteBook(ctx, tx, &api.DeleteBookRequest{BookId: bookID, ExpectedVersion: res.Book.Version})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = inTx(ctx, pool, func(tx pgx.Tx) error {
+		_, err := NewLoanQuery(pool, testRules).CheckoutCopy(ctx, tx, &api.Loan{Id: uuid.New().String(), CopyId: copyID, UserId: uuid.New().String(), CheckedOutAt: now})
+		return err
+	})
+	if !errs.Is(err, errs.NotFound) {
+		t.Errorf("checkout = %v, want NotFound", err)
+	}
+}
@@ -3,50 +3,53 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	api "github.com/daffaromero/gobook/protobuf/api"
 	"github.com/daffaromero/gobook/services/book-service/repository"
-	"github.com/daffaromero/gobook/services/common/discovery"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/fieldmask"
 	"github.com/daffaromero/gobook/services/common/helper/logger"
-	"github.com/gofiber/fiber/v3"
+	"github.com/daffaromero/gobook/services/common/outbox"
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type BookService interface {
 	GetBook(ctx context.Context, req *api.GetBookRequest) (*api.GetBookResponse, error)
 	ListBooks(ctx context.Context, req *api.ListBooksRequest) (*api.ListBooksResponse, error)
+	SearchBooks(ctx context.Context, req *api.SearchBooksRequest) (*api.SearchBooksResponse, error)
 	CreateBook(ctx context.Context, req *api.CreateBookRequest, title string, author string, categoryId string, description string) (*api.CreateBookResponse, error)
 	UpdateBook(ctx context.Context, req *api.UpdateBookRequest, title string, author string, categoryId string, description string) (*api.UpdateBookResponse, error)
 	DeleteBook(ctx context.Context, req *api.DeleteBookRequest) (*api.DeleteBookResponse, error)
+	ListDeletedBooks(ctx context.Context, req *api.ListDeletedBooksRequest) (*api.ListDeletedBooksResponse, error)
+	RestoreBook(ctx context.Context, req *api.RestoreBookRequest) (*api.RestoreBookResponse, error)
+	PurgeBooks(ctx context.Context, retention time.Duration) (int64, error)
+	CountBooksByCategory(ctx context.Context, req *api.CountBooksByCategoryRequest) (*api.CountBooksByCategoryResponse, error)
+	ReassignCategory(ctx context.Context, req *api.ReassignCategoryRequest) (*api.ReassignCategoryResponse, error)
+	DeleteBooksByCategory(ctx context.Context, req *api.DeleteBooksByCategoryRequest) (*api.DeleteBooksByCategoryResponse, error)
+	CloseCategory(ctx context.Context, req *api.CloseCategoryRequest) (*api.CloseCategoryResponse, error)
+	ReopenCategory(ctx context.Context, req *api.ReopenCategoryRequest) (*api.ReopenCategoryResponse, error)
+	ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error)
+	WatchBooks(ctx context.Context, req *api.WatchBooksRequest, send func(*api.BookEvent) error) error
 }
 
 type bookService struct {
-	client   api.BookCategoryServiceClient
-	registry discovery.Registry
-	repo     repository.BookRepository
-	logger   *logger.Log
+	categories CategoryLookup
+	repo       repository.BookRepository
+	logger     *logger.Log
 }
 
-func NewBookService(ctx context.Context, registry discovery.Registry, repo repository.BookRepository, logger *logger.Log) (*bookService, error) {
-	conn, err := discovery.ServiceConnection(ctx, "book-category-service-grpc", registry)
-	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to book-category-service: %v", err))
-
-		return nil, err
-	}
-	logger.Log(fmt.Sprintf("Connected to book-category-service at %s", conn.Target()))
-
-	client := api.NewBookCategoryServiceClient(conn)
-
+func NewBookService(categories CategoryLookup, repo repository.BookRepository, logger *logger.Log) *bookService {
 	return &bookService{
-		client:   client,
-		registry: registry,
-		repo:     repo,
-		logger:   logger,
-	}, nil
+		categories: categories,
+		repo:       repo,
+		logger:     logger,
+	}
 }
 
 func (s *bookService) GetBook(ctx context.Context, req *api.GetBookRequest) (*api.GetBookResponse, error) {
@@ -59,6 +62,10 @@ func (s *bookService) GetBook(ctx context.Context, req *api.GetBookRequest) (*ap
 }
 
 func (s *bookService) ListBooks(ctx context.Context, req *api.ListBooksRequest) (*api.ListBooksResponse, error) {
+	if req == nil {
+		req = &api.ListBooksRequest{}
+	}
+
 	books, err := s.repo.ListBooks(ctx, req)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("Failed to list books: %v", err))
@@ -67,18 +74,33 @@ func (s *bookService) ListBooks(ctx context.Context, req *api.ListBooksRequest)
 	return books, nil
 }
 
-func (s *bookService) CreateBook(ctx context.Context, req *api.CreateBookRequest, title string, author string, categoryId string, description string) (*api.CreateBookResponse, error) {
-	if s.client == nil {
-		s.logger.Error("gRPC client is not initialized")
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "Book Category Service is not available.")
+func (s *bookService) SearchBooks(ctx context.Context, req *api.SearchBooksRequest) (*api.SearchBooksResponse, error) {
+	if req == nil || strings.TrimSpace(req.Query) == "" {
+		return nil, errs.New(errs.InvalidArgument, "search query cannot be empty")
+	}
+
+	results, err := s.repo.SearchBooks(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to search books: %v", err))
+		return nil, err
 	}
-	categoryReq := &api.GetCategoryRequest{
-		CategoryId: categoryId,
+	return results, nil
+}
+
+// categoryError classifies a failed category lookup. A missing category is
+// bad input for the book being written, not a missing book.
+func categoryError(err error, categoryID string) error {
+	if errs.Is(err, errs.NotFound) {
+		return errs.Newf(errs.InvalidArgument, "category with ID %s not found", categoryID)
 	}
-	category, err := s.client.GetCategory(ctx, categoryReq)
-	if err != nil || category == nil || category.Category == nil {
+	return err
+}
+
+func (s *bookService) CreateBook(ctx context.Context, req *api.CreateBookRequest, title string, author string, categoryId string, description string) (*api.CreateBookResponse, error) {
+	category, err := s.categories.GetCategory(ctx, categoryId)
+	if err != nil {
 		s.logger.Error(fmt.Sprintf("(RPC) Failed to get category: %v", err))
-		return nil, err
+		return nil, categoryError(err, categoryId)
 	}
 
 	now := &timestamppb.Timestamp{
@@ -88,7 +110,7 @@ func (s *bookService) CreateBook(ctx context.Context, req *api.CreateBookRequest
 	req.Book.Id = uuid.New().String()
 	req.Book.Title = title
 	req.Book.Author = author
-	req.Book.CategoryId = category.Category.Id
+	req.Book.CategoryId = category.Id
 	req.Book.Description = description
 	req.Book.CreatedAt = now
 	req.Book.UpdatedAt = now
@@ -96,8 +118,8 @@ func (s *bookService) CreateBook(ctx context.Context, req *api.CreateBookRequest
 	res, err := s.repo.CreateBook(ctx, req)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("Failed to create book: %v", err))
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-			return nil, fiber.NewError(fiber.StatusBadRequest, "Book already exists.")
+		if errs.Is(err, errs.AlreadyExists) {
+			return nil, errs.New(errs.AlreadyExists, "Book already exists.")
 		}
 		return nil, err
 	}
@@ -105,25 +127,77 @@ func (s *bookService) CreateBook(ctx context.Context, req *api.CreateBookRequest
 	return res, nil
 }
 
-func (s *bookService) UpdateBook(ctx context.Context, req *api.UpdateBookRequest, title string, author string, categoryId string, description string) (*api.UpdateBookResponse, error) {
-	categoryReq := &api.GetCategoryRequest{
-		CategoryId: categoryId,
+// bookFields are the book fields an update mask may name.
+var bookFields = []string{"title", "author", "category_id", "description"}
+
+// bookMask resolves the fields an update writes. Without a mask, the
+// non-empty fields of book are written.
+func bookMask(req *api.UpdateBookRequest) ([]string, error) {
+	if len(req.UpdateMask.GetPaths()) > 0 {
+		paths, err := fieldmask.Resolve(req.UpdateMask, bookFields...)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			if path != "description" && bookField(req.Book, path) == "" {
+				return nil, errs.Newf(errs.InvalidArgument, "%s cannot be empty", path)
+			}
+		}
+		return paths, nil
+	}
+
+	var paths []string
+	for _, path := range bookFields {
+		if bookField(req.Book, path) != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func bookField(book *api.Book, path string) string {
+	switch path {
+	case "title":
+		return book.Title
+	case "author":
+		return book.Author
+	case "category_id":
+		return book.CategoryId
+	case "description":
+		return book.Description
 	}
+	return ""
+}
+
+func (s *bookService) UpdateBook(ctx context.Context, req *api.UpdateBookRequest, title string, author string, categoryId string, description string) (*api.UpdateBookResponse, error) {
+	req.Book.Title = title
+	req.Book.Author = author
+	req.Book.CategoryId = categoryId
+	req.Book.Description = description
 
-	category, err := s.client.GetCategory(ctx, categoryReq)
-	if err != nil || category == nil || category.Category == nil {
-		s.logger.Error(fmt.Sprintf("Failed to get category: %v", err))
+	paths, err := bookMask(req)
+	if err != nil {
 		return nil, err
 	}
+	if len(paths) == 0 {
+		return nil, errs.New(errs.InvalidArgument, "no fields to update")
+	}
+	req.UpdateMask = &fieldmaskpb.FieldMask{Paths: paths}
+
+	// The category only needs checking when the book is moving to another one.
+	if slices.Contains(paths, "category_id") {
+		category, err := s.categories.GetCategory(ctx, categoryId)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to get category: %v", err))
+			return nil, categoryError(err, categoryId)
+		}
+		req.Book.CategoryId = category.Id
+	}
 
 	now := &timestamppb.Timestamp{
 		Seconds: time.Now().Unix(),
 		Nanos:   int32(time.Now().Nanosecond()),
 	}
-	req.Book.Title = title
-	req.Book.Author = author
-	req.Book.CategoryId = category.Category.Id
-	req.Book.Description = description
 	req.Book.UpdatedAt = now
 
 	res, err := s.repo.UpdateBook(ctx, req)
@@ -143,3 +217,179 @@ func (s *bookService) DeleteBook(ctx context.Context, req *api.DeleteBookRequest
 	}
 	return res, nil
 }
+
+func (s *bookService) ListDeletedBooks(ctx context.Context, req *api.ListDeletedBooksRequest) (*api.ListDeletedBooksResponse, error) {
+	if req == nil {
+		req = &api.ListDeletedBooksRequest{}
+	}
+
+	books, err := s.repo.ListDeletedBooks(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list deleted books: %v", err))
+		return nil, err
+	}
+	return books, nil
+}
+
+// RestoreBook takes a book out of the trash. Its category may have been
+// deleted in the meantime, so it is checked again first.
+func (s *bookService) RestoreBook(ctx context.Context, req *api.RestoreBookRequest) (*api.RestoreBookResponse, error) {
+	book, err := s.repo.GetDeletedBook(ctx, req.BookId)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to restore book: %v", err))
+		return nil, err
+	}
+
+	if book.CategoryId != "" {
+		_, err := s.categories.GetCategory(ctx, book.CategoryId)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("(RPC) Failed to get category: %v", err))
+			if !errs.Is(err, errs.NotFound) {
+				return nil, err
+			}
+			return nil, errs.Newf(errs.Conflict, "category with ID %s no longer exists, restore the category first", book.CategoryId)
+		}
+	}
+
+	res, err := s.repo.RestoreBook(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to restore book: %v", err))
+		return nil, err
+	}
+	return res, nil
+}
+
+// PurgeBooks permanently removes books that have been in the trash for longer
+// than retention.
+func (s *bookService) PurgeBooks(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.repo.PurgeBooks(ctx, time.Now().Add(-retention))
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to purge books: %v", err))
+		return 0, err
+	}
+
+	if purged.Books > 0 {
+		s.logger.Log(fmt.Sprintf("Purged %d book(s) deleted more than %s ago, keeping %d of their loan(s) as history", purged.Books, retention, purged.Loans))
+	}
+	if purged.Held > 0 {
+		s.logger.Log(fmt.Sprintf("Kept %d book(s) deleted more than %s ago in the trash: they have loans still open or accruing fines", purged.Held, retention))
+	}
+	return purged.Books, nil
+}
+
+func (s *bookService) CountBooksByCategory(ctx context.Context, req *api.CountBooksByCategoryRequest) (*api.CountBooksByCategoryResponse, error) {
+	count, err := s.repo.CountBooksByCategory(ctx, req.CategoryId)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to count books in category: %v", err))
+		return nil, err
+	}
+
+	return &api.CountBooksByCategoryResponse{
+		Count: count,
+	}, nil
+}
+
+func (s *bookService) ReassignCategory(ctx context.Context, req *api.ReassignCategoryRequest) (*api.ReassignCategoryResponse, error) {
+	if req.FromCategoryId == req.ToCategoryId {
+		return nil, errs.New(errs.InvalidArgument, "books cannot be reassigned to the same category")
+	}
+
+	if _, err := s.categories.GetCategory(ctx, req.ToCategoryId); err != nil {
+		s.logger.Error(fmt.Sprintf("(RPC) Failed to get category: %v", err))
+		return nil, categoryError(err, req.ToCategoryId)
+	}
+
+	moved, err := s.repo.ReassignCategory(ctx, req.FromCategoryId, req.ToCategoryId)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to reassign books: %v", err))
+		return nil, err
+	}
+
+	return &api.ReassignCategoryResponse{
+		Moved: moved,
+	}, nil
+}
+
+func (s *bookService) DeleteBooksByCategory(ctx context.Context, req *api.DeleteBooksByCategoryRequest) (*api.DeleteBooksByCategoryResponse, error) {
+	deleted, err := s.repo.DeleteBooksByCategory(ctx, req.CategoryId)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to delete books in category: %v", err))
+		return nil, err
+	}
+
+	return &api.DeleteBooksByCategoryResponse{
+		Deleted: deleted,
+	}, nil
+}
+
+// CloseCategory is called by book-category-service before it deletes a
+// category. Once it returns, no book can be put in the category, whatever
+// this instance's category cache says, until ReopenCategory is called.
+func (s *bookService) CloseCategory(ctx context.Context, req *api.CloseCategoryRequest) (*api.CloseCategoryResponse, error) {
+	if req.Mode == api.DeleteCategoryMode_DELETE_CATEGORY_MODE_REASSIGN {
+		if req.FallbackCategoryId == "" || req.FallbackCategoryId == req.CategoryId {
+			return nil, errs.New(errs.InvalidArgument, "a different fallback category is required to reassign books")
+		}
+		if _, err := s.categories.GetCategory(ctx, req.FallbackCategoryId); err != nil {
+			s.logger.Error(fmt.Sprintf("(RPC) Failed to get category: %v", err))
+			return nil, categoryError(err, req.FallbackCategoryId)
+		}
+	}
+
+	affected, err := s.repo.CloseCategory(ctx, req.CategoryId, req.Mode, req.FallbackCategoryId)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to close category: %v", err))
+		return nil, err
+	}
+
+	return &api.CloseCategoryResponse{
+		AffectedBooks: affected,
+	}, nil
+}
+
+func (s *bookService) ReopenCategory(ctx context.Context, req *api.ReopenCategoryRequest) (*api.ReopenCategoryResponse, error) {
+	if err := s.repo.ReopenCategory(ctx, req.CategoryId); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to reopen category: %v", err))
+		return nil, err
+	}
+	return &api.ReopenCategoryResponse{}, nil
+}
+
+func (s *bookService) ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error) {
+	if req == nil {
+		req = &api.ListAuditEntriesRequest{}
+	}
+	if req.Since != nil && req.Until != nil && !req.Since.AsTime().Before(req.Until.AsTime()) {
+		return nil, errs.New(errs.InvalidArgument, "since must be before until")
+	}
+
+	entries, err := s.repo.ListAuditEntries(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list audit entries: %v", err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WatchBooks sends each change after req.AfterSequence to send, waiting for
+// send to return before reading on, until ctx is cancelled or send fails.
+func (s *bookService) WatchBooks(ctx context.Context, req *api.WatchBooksRequest, send func(*api.BookEvent) error) error {
+	err := s.repo.WatchBooks(ctx, req.GetAfterSequence(), func(e outbox.Event) error {
+		var book api.Book
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(e.Payload, &book); err != nil {
+			return fmt.Errorf("failed to decode %s event %s: %w", e.Topic, e.ID, err)
+		}
+
+		return send(&api.BookEvent{
+			Sequence:  e.Sequence,
+			Type:      e.ChangeType(),
+			Book:      &book,
+			RequestId: e.RequestID,
+			CreatedAt: timestamppb.New(e.CreatedAt),
+		})
+	})
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error(fmt.Sprintf("Failed to watch books: %v", err))
+	}
+	return err
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/discovery"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/daffaromero/gobook/services/common/outbox"
+)
+
+// CategoryLookup finds categories in book-category-service.
+type CategoryLookup interface {
+	// GetCategory returns the category with the given ID, or a NotFound
+	// error. The category is shared and must not be modified.
+	GetCategory(ctx context.Context, id string) (*api.BookCategory, error)
+}
+
+type CategoryCacheConfig struct {
+	// TTL is how long a category is served without asking
+	// book-category-service again.
+	TTL time.Duration
+	// NegativeTTL is how long a category is known not to exist.
+	NegativeTTL time.Duration
+	// MaxStale is how long past its TTL a category is still served while it
+	// is refreshed in the background, so a slow or unavailable
+	// book-category-service does not hold up book writes.
+	MaxStale time.Duration
+	// MaxEntries bounds the cache, so lookups of made up IDs cannot grow it
+	// without limit.
+	MaxEntries int
+}
+
+// categoryEntry is a cached lookup. A nil category records that the
+// category does not exist.
+type categoryEntry struct {
+	category  *api.BookCategory
+	fetchedAt time.Time
+}
+
+// refreshTimeout bounds a background refresh of a stale category.
+const refreshTimeout = 10 * time.Second
+
+// CategoryCache is a CategoryLookup that keeps categories for a while, so
+// book writes do not depend on book-category-service for every request.
+// Watch drops categories as soon as they change.
+type CategoryCache struct {
+	client api.BookCategoryServiceClient
+	config CategoryCacheConfig
+	logger *logger.Log
+
+	mu      sync.Mutex
+	entries map[string]categoryEntry
+	// generation changes whenever entries are invalidated, so a lookup that
+	// raced with an invalidation does not store what it fetched.
+	generation uint64
+	// refreshing holds the categories being refreshed in the background.
+	refreshing map[string]bool
+}
+
+func NewCategoryCache(ctx context.Context, registry discovery.Registry, config CategoryCacheConfig, logger *logger.Log) (*CategoryCache, error) {
+	conn, err := discovery.ServiceConnection(ctx, "book-category-service-grpc", registry)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to book-category-service: %v", err))
+
+		return nil, err
+	}
+	logger.Log(fmt.Sprintf("Connected to book-category-service at %s", conn.Target()))
+
+	return &CategoryCache{
+		client:     api.NewBookCategoryServiceClient(conn),
+		config:     config,
+		logger:     logger,
+		entries:    make(map[string]categoryEntry),
+		refreshing: make(map[string]bool),
+	}, nil
+}
+
+func (c *CategoryCache) GetCategory(ctx context.Context, id string) (*api.BookCategory, error) {
+	c.mu.Lock()
+	entry, cached := c.entries[id]
+	generation := c.generation
+	c.mu.Unlock()
+
+	if cached {
+		age := time.Since(entry.fetchedAt)
+		switch {
+		case age < c.ttl(entry):
+			if entry.category == nil {
+				return nil, errs.Newf(errs.NotFound, "category with ID %s not found", id)
+			}
+			return entry.category, nil
+
+		case entry.category != nil && age < c.config.TTL+c.config.MaxStale:
+			c.revalidate(id, entry, generation)
+			return entry.category, nil
+		}
+	}
+
+	return c.fetch(ctx, id, generation)
+}
+
+// fetch looks a category up in book-category-service and caches the answer.
+func (c *CategoryCache) fetch(ctx context.Context, id string, generation uint64) (*api.BookCategory, error) {
+	res, err := c.client.GetCategory(ctx, &api.GetCategoryRequest{CategoryId: id})
+	err = errs.FromStatus(err)
+	switch {
+	case err == nil && res.GetCategory() != nil:
+		c.store(id, res.Category, generation)
+		return res.Category, nil
+
+	case err == nil || errs.Is(err, errs.NotFound):
+		c.store(id, nil, generation)
+		return nil, errs.Newf(errs.NotFound, "category with ID %s not found", id)
+	}
+	return nil, err
+}
+
+// revalidate refreshes a stale entry in the background, one refresh per
+// category at a time. The stale entry is served until the refresh succeeds
+// or the entry is too old to serve.
+func (c *CategoryCache) revalidate(id string, entry categoryEntry, generation uint64) {
+	c.mu.Lock()
+	if c.refreshing[id] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[id] = true
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, id)
+			c.mu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+		if _, err := c.fetch(ctx, id, generation); err != nil && !errs.Is(err, errs.NotFound) {
+			c.logger.Error(fmt.Sprintf("(RPC) Failed to refresh category %s, serving cached copy from %s: %v", id, entry.fetchedAt.Format(time.RFC3339), err))
+		}
+	}()
+}
+
+func (c *CategoryCache) ttl(entry categoryEntry) time.Duration {
+	if entry.category == nil {
+		return c.config.NegativeTTL
+	}
+	return c.config.TTL
+}
+
+func (c *CategoryCache) store(id string, category *api.BookCategory, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+
+	if _, ok := c.entries[id]; !ok && len(c.entries) >= c.config.MaxEntries {
+		c.sweep()
+		if len(c.entries) >= c.config.MaxEntries {
+			return
+		}
+	}
+	c.entries[id] = categoryEntry{category: category, fetchedAt: time.Now()}
+}
+
+// sweep drops entries that can no longer be served. c.mu must be held.
+func (c *CategoryCache) sweep() {
+	now := time.Now()
+	for id, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= c.ttl(entry)+c.config.MaxStale {
+			delete(c.entries, id)
+		}
+	}
+}
+
+func (c *CategoryCache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+	c.generation++
+}
+
+func (c *CategoryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]categoryEntry)
+	c.generation++
+}
+
+// Watch follows category changes and drops the changed categories from the
+// cache, reconnecting until ctx is cancelled. The cache starts empty, so it
+// follows changes from the next one rather than replaying the retained ones.
+// If it cannot resume where it left off it clears the cache, since it may
+// have missed changes.
+func (c *CategoryCache) Watch(ctx context.Context) {
+	const maxBackoff = time.Minute
+
+	var after int64 = outbox.FromNext
+	backoff := time.Second
+	for {
+		resumed := after
+		err := c.follow(ctx, &after)
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case errs.Is(err, errs.FailedPrecondition):
+			c.logger.Error(fmt.Sprintf("Category changes after %d are no longer available, clearing cache", after))
+			c.clear()
+			after = outbox.FromNext
+		case after == outbox.FromNext:
+			// No change arrived to resume from, so any made while
+			// reconnecting would be missed.
+			c.logger.Error(fmt.Sprintf("(RPC) Lost category change stream before any change, clearing cache: %v", err))
+			c.clear()
+		default:
+			c.logger.Error(fmt.Sprintf("(RPC) Lost category change stream: %v", err))
+		}
+
+		if after > resumed {
+			backoff = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (c *CategoryCache) follow(ctx context.Context, after *int64) error {
+	stream, err := c.client.WatchCategories(ctx, &api.WatchCategoriesRequest{AfterSequence: *after})
+	if err != nil {
+		return errs.FromStatus(err)
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return errs.FromStatus(err)
+		}
+		c.invalidate(event.Category.GetId())
+		*after = event.Sequence
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/daffaromero/gobook/services/common/outbox"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// categoryClient answers every lookup with category and records where
+// category watches start.
+type categoryClient struct {
+	api.BookCategoryServiceClient
+
+	category *api.BookCategory
+	lookups  atomic.Int32
+	watches  chan int64
+}
+
+func (c *categoryClient) GetCategory(ctx context.Context, req *api.GetCategoryRequest, opts ...grpc.CallOption) (*api.GetCategoryResponse, error) {
+	c.lookups.Add(1)
+	return &api.GetCategoryResponse{Category: c.category}, nil
+}
+
+func (c *categoryClient) WatchCategories(ctx context.Context, req *api.WatchCategoriesRequest, opts ...grpc.CallOption) (api.BookCategoryService_WatchCategoriesClient, error) {
+	c.watches <- req.AfterSequence
+	return nil, status.Error(codes.Unavailable, "book-category-service is down")
+}
+
+func newTestCache(client *categoryClient) *CategoryCache {
+	return &CategoryCache{
+		client:     client,
+		config:     CategoryCacheConfig{TTL: time.Minute, NegativeTTL: time.Minute, MaxStale: time.Hour, MaxEntries: 10},
+		logger:     logger.New("test"),
+		entries:    make(map[string]categoryEntry),
+		refreshing: make(map[string]bool),
+	}
+}
+
+func (c *CategoryCache) cached(id string) *api.BookCategory {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[id].category
+}
+
+// A stale category is served at once and refreshed in the background; one
+// too old to serve is looked up before answering.
+func TestCategoryCacheRefreshesStaleEntries(t *testing.T) {
+	client := &categoryClient{category: &api.BookCategory{Id: "fiction", Name: "Novels"}}
+	cache := newTestCache(client)
+
+	cache.entries["fiction"] = categoryEntry{
+		category:  &api.BookCategory{Id: "fiction", Name: "Fiction"},
+		fetchedAt: time.Now().Add(-2 * time.Minute),
+	}
+	got, err := cache.GetCategory(context.Background(), "fiction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Fiction" {
+		t.Errorf("stale lookup = %q, want the cached %q", got.Name, "Fiction")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.cached("fiction").GetName() != "Novels" {
+		if time.Now().After(deadline) {
+			t.Fatalf("stale category was not refreshed in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cache.mu.Lock()
+	cache.entries["fiction"] = categoryEntry{
+		category:  &api.BookCategory{Id: "fiction", Name: "Fiction"},
+		fetchedAt: time.Now().Add(-2 * time.Hour),
+	}
+	cache.mu.Unlock()
+	got, err = cache.GetCategory(context.Background(), "fiction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Novels" {
+		t.Errorf("expired lookup = %q, want the refreshed %q", got.Name, "Novels")
+	}
+	if n := client.lookups.Load(); n != 2 {
+		t.Errorf("%d lookups, want 2", n)
+	}
+}
+
+// A cache that has just started has nothing to catch up on, so it watches
+// from the next change instead of replaying the retained ones.
+func TestCategoryCacheWatchesFromNextChange(t *testing.T) {
+	client := &categoryClient{watches: make(chan int64, 1)}
+	cache := newTestCache(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Watch(ctx)
+	}()
+
+	if after := <-client.watches; after != outbox.FromNext {
+		t.Errorf("watch started after %d, want %d", after, outbox.FromNext)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/repository"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var copyConditions = []string{"new", "good", "fair", "poor", "damaged"}
+
+type CopyService interface {
+	ListCopies(ctx context.Context, req *api.ListCopiesRequest) (*api.ListCopiesResponse, error)
+	AddCopy(ctx context.Context, req *api.AddCopyRequest) (*api.AddCopyResponse, error)
+	RetireCopy(ctx context.Context, req *api.RetireCopyRequest) (*api.RetireCopyResponse, error)
+	RelocateCopy(ctx context.Context, req *api.RelocateCopyRequest) (*api.RelocateCopyResponse, error)
+}
+
+type copyService struct {
+	repo   repository.CopyRepository
+	logger *logger.Log
+}
+
+func NewCopyService(repo repository.CopyRepository, logger *logger.Log) *copyService {
+	return &copyService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *copyService) ListCopies(ctx context.Context, req *api.ListCopiesRequest) (*api.ListCopiesResponse, error) {
+	copies, err := s.repo.ListCopies(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list copies: %v", err))
+		return nil, err
+	}
+	return copies, nil
+}
+
+func (s *copyService) AddCopy(ctx context.Context, req *api.AddCopyRequest) (*api.AddCopyResponse, error) {
+	if req.Copy == nil || req.Copy.BookId == "" || strings.TrimSpace(req.Copy.Barcode) == "" {
+		return nil, errs.New(errs.InvalidArgument, "book_id and barcode are required")
+	}
+	if req.Copy.Condition == "" {
+		req.Copy.Condition = "good"
+	}
+	if !slices.Contains(copyConditions, req.Copy.Condition) {
+		return nil, errs.Newf(errs.InvalidArgument, "condition must be one of %s", strings.Join(copyConditions, ", "))
+	}
+
+	req.Copy.Id = uuid.New().String()
+	req.Copy.Barcode = strings.TrimSpace(req.Copy.Barcode)
+	req.Copy.CreatedAt = timestamppb.New(time.Now())
+
+	res, err := s.repo.AddCopy(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to add copy: %v", err))
+		if errs.Is(err, errs.AlreadyExists) {
+			return nil, errs.New(errs.AlreadyExists, "A copy with this barcode already exists.")
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *copyService) RetireCopy(ctx context.Context, req *api.RetireCopyRequest) (*api.RetireCopyResponse, error) {
+	res, err := s.repo.RetireCopy(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to retire copy: %v", err))
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *copyService) RelocateCopy(ctx context.Context, req *api.RelocateCopyRequest) (*api.RelocateCopyResponse, error) {
+	if strings.TrimSpace(req.Location) == "" {
+		return nil, errs.New(errs.InvalidArgument, "location is required")
+	}
+	req.Location = strings.TrimSpace(req.Location)
+
+	res, err := s.repo.RelocateCopy(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to relocate copy: %v", err))
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/repository"
+	"github.com/daffaromero/gobook/services/book-service/repository/query"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type FineService interface {
+	GetBalance(ctx context.Context, req *api.GetBalanceRequest) (*api.GetBalanceResponse, error)
+	ListLedger(ctx context.Context, req *api.ListLedgerRequest) (*api.ListLedgerResponse, error)
+	WaiveFine(ctx context.Context, req *api.WaiveFineRequest) (*api.WaiveFineResponse, error)
+	RecordPayment(ctx context.Context, req *api.RecordPaymentRequest) (*api.RecordPaymentResponse, error)
+	AccrueFines(ctx context.Context) (int64, error)
+}
+
+type fineService struct {
+	categories CategoryLookup
+	defaults   *api.FinePolicy
+	repo       repository.FineRepository
+	logger     *logger.Log
+}
+
+func NewFineService(categories CategoryLookup, defaults *api.FinePolicy, repo repository.FineRepository, logger *logger.Log) *fineService {
+	return &fineService{
+		categories: categories,
+		defaults:   defaults,
+		repo:       repo,
+		logger:     logger,
+	}
+}
+
+// fineFor is the late fee owed for a loan that was due at due and stayed out
+// until end. Whole or part days count as full days.
+func fineFor(p *api.FinePolicy, due, end time.Time) int64 {
+	if !end.After(due) {
+		return 0
+	}
+
+	days := int64(math.Ceil(end.Sub(due).Hours()/24)) - int64(p.GraceDays)
+	if days <= 0 {
+		return 0
+	}
+
+	fine := days * p.DailyRateCents
+	if p.CapCents > 0 {
+		fine = min(fine, p.CapCents)
+	}
+	return fine
+}
+
+// policyLookup is the outcome of looking a category's fine policy up.
+type policyLookup struct {
+	policy *api.FinePolicy
+	err    error
+}
+
+// policyFor returns the category's fine policy, or the defaults when the
+// loan's book has no category, the category has no policy of its own or the
+// category no longer exists. Any other failure is returned, and remembered in
+// cache along with successful lookups for the rest of the run.
+func (s *fineService) policyFor(ctx context.Context, categoryID string, cache map[string]policyLookup) (*api.FinePolicy, error) {
+	if l, ok := cache[categoryID]; ok {
+		return l.policy, l.err
+	}
+
+	l := policyLookup{policy: s.defaults}
+	if categoryID != "" {
+		category, err := s.categories.GetCategory(ctx, categoryID)
+		switch {
+		case errs.Is(err, errs.NotFound):
+		case err != nil:
+			l = policyLookup{err: err}
+		case category.GetFinePolicy() != nil:
+			l.policy = category.FinePolicy
+		}
+	}
+
+	cache[categoryID] = l
+	return l.policy, l.err
+}
+
+// AccrueFines brings every overdue loan's fine up to date and returns the
+// total amount charged. It is safe to run repeatedly. Loans whose category
+// cannot be fetched are left for a later run: charging them the default rate
+// would close the fines of returned loans at the wrong amount.
+func (s *fineService) AccrueFines(ctx context.Context) (int64, error) {
+	candidates, err := s.repo.ListFineCandidates(ctx)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to accrue fines: %v", err))
+		return 0, err
+	}
+
+	now := time.Now()
+	policies := map[string]policyLookup{}
+	var total int64
+	skipped := 0
+	for _, loan := range candidates {
+		policy, err := s.policyFor(ctx, loan.CategoryID, policies)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		end := now
+		if loan.ReturnedAt != nil {
+			end = *loan.ReturnedAt
+		}
+
+		charged, err := s.repo.ChargeFine(ctx, loan, fineFor(policy, loan.DueAt, end))
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to charge fine for loan %s: %v", loan.LoanID, err))
+			continue
+		}
+		total += charged
+	}
+
+	for categoryID, l := range policies {
+		if l.err != nil {
+			s.logger.Error(fmt.Sprintf("(RPC) Failed to get fine policy for category %s: %v", categoryID, l.err))
+		}
+	}
+	if skipped > 0 {
+		s.logger.Error(fmt.Sprintf("Skipped %d overdue loan(s) whose fine policy could not be fetched", skipped))
+	}
+	if total > 0 {
+		s.logger.Log(fmt.Sprintf("Accrued %d cents of fines over %d overdue loan(s)", total, len(candidates)-skipped))
+	}
+	return total, nil
+}
+
+func (s *fineService) GetBalance(ctx context.Context, req *api.GetBalanceRequest) (*api.GetBalanceResponse, error) {
+	cents, err := s.repo.GetBalance(ctx, req.UserId)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to get balance: %v", err))
+		return nil, err
+	}
+
+	return &api.GetBalanceResponse{
+		UserId:       req.UserId,
+		BalanceCents: cents,
+	}, nil
+}
+
+func (s *fineService) ListLedger(ctx context.Context, req *api.ListLedgerRequest) (*api.ListLedgerResponse, error) {
+	ledger, err := s.repo.ListLedger(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list ledger: %v", err))
+		return nil, err
+	}
+	return ledger, nil
+}
+
+func (s *fineService) credit(ctx context.Context, entry *api.LedgerEntry) (*api.LedgerEntry, error) {
+	if entry.UserId == "" || entry.AmountCents <= 0 {
+		return nil, errs.New(errs.InvalidArgument, "user_id and a positive amount_cents are required")
+	}
+
+	entry.Id = uuid.New().String()
+	entry.CreatedAt = timestamppb.New(time.Now())
+	if claims, ok := auth.FromContext(ctx); ok {
+		entry.CreatedBy = claims.Subject
+	}
+
+	res, err := s.repo.Credit(ctx, entry)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to record %s: %v", entry.Kind, err))
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *fineService) WaiveFine(ctx context.Context, req *api.WaiveFineRequest) (*api.WaiveFineResponse, error) {
+	res, err := s.credit(ctx, &api.LedgerEntry{
+		UserId:      req.UserId,
+		LoanId:      req.LoanId,
+		Kind:        query.LedgerWaiver,
+		AmountCents: req.AmountCents,
+		Note:        req.Note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.WaiveFineResponse{
+		Entry: res,
+	}, nil
+}
+
+func (s *fineService) RecordPayment(ctx context.Context, req *api.RecordPaymentRequest) (*api.RecordPaymentResponse, error) {
+	res, err := s.credit(ctx, &api.LedgerEntry{
+		UserId:      req.UserId,
+		Kind:        query.LedgerPayment,
+		AmountCents: req.AmountCents,
+		Note:        req.Note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.RecordPaymentResponse{
+		Entry: res,
+	}, nil
+}
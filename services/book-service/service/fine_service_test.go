@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/repository"
+	"github.com/daffaromero/gobook/services/book-service/repository/query"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+)
+
+// fineLedger lists loans as fine candidates and records what is charged.
+type fineLedger struct {
+	repository.FineRepository
+
+	candidates []query.FineCandidate
+	charged    map[string]int64
+}
+
+func (r *fineLedger) ListFineCandidates(ctx context.Context) ([]query.FineCandidate, error) {
+	return r.candidates, nil
+}
+
+func (r *fineLedger) ChargeFine(ctx context.Context, loan query.FineCandidate, total int64) (int64, error) {
+	r.charged[loan.LoanID] = total
+	return total, nil
+}
+
+// categoryLookup answers from a map of categories, failing with failures[id]
+// or NotFound for the IDs it does not hold.
+type categoryLookup struct {
+	categories map[string]*api.BookCategory
+	failures   map[string]error
+}
+
+func (c categoryLookup) GetCategory(ctx context.Context, id string) (*api.BookCategory, error) {
+	if category, ok := c.categories[id]; ok {
+		return category, nil
+	}
+	if err, ok := c.failures[id]; ok {
+		return nil, err
+	}
+	return nil, errs.Newf(errs.NotFound, "category %s not found", id)
+}
+
+func TestAccrueFinesSkipsUnknownPolicies(t *testing.T) {
+	returned := time.Now().Add(-24 * time.Hour)
+	due := returned.Add(-3 * 24 * time.Hour)
+	loan := func(id, categoryID string) query.FineCandidate {
+		return query.FineCandidate{LoanID: id, UserID: "member", CategoryID: categoryID, DueAt: due, ReturnedAt: &returned}
+	}
+
+	ledger := &fineLedger{
+		candidates: []query.FineCandidate{
+			loan("own-policy", "rare"),
+			loan("deleted-category", "gone"),
+			loan("uncategorised", ""),
+			loan("category-service-down", "fiction"),
+		},
+		charged: map[string]int64{},
+	}
+	categories := categoryLookup{
+		categories: map[string]*api.BookCategory{
+			"rare": {Id: "rare", FinePolicy: &api.FinePolicy{DailyRateCents: 100}},
+		},
+		failures: map[string]error{
+			"fiction": errs.New(errs.Unavailable, "book-category-service is not available"),
+		},
+	}
+	svc := NewFineService(categories, &api.FinePolicy{DailyRateCents: 10}, ledger, logger.New("test"))
+
+	if _, err := svc.AccrueFines(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int64{"own-policy": 300, "deleted-category": 30, "uncategorised": 30}
+	for id, cents := range want {
+		if got, ok := ledger.charged[id]; !ok || got != cents {
+			t.Errorf("loan %s charged %d (charged: %t), want %d", id, got, ok, cents)
+		}
+	}
+	if got, ok := ledger.charged["category-service-down"]; ok {
+		t.Errorf("loan whose policy could not be fetched was charged %d, want it skipped", got)
+	}
+}
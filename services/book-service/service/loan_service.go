@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/book-service/repository"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/discovery"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type LoanService interface {
+	CheckoutCopy(ctx context.Context, req *api.CheckoutCopyRequest) (*api.CheckoutCopyResponse, error)
+	ReturnCopy(ctx context.Context, req *api.ReturnCopyRequest) (*api.ReturnCopyResponse, error)
+	RenewLoan(ctx context.Context, req *api.RenewLoanRequest) (*api.RenewLoanResponse, error)
+	ListLoans(ctx context.Context, req *api.ListLoansRequest) (*api.ListLoansResponse, error)
+	PlaceHold(ctx context.Context, req *api.PlaceHoldRequest) (*api.PlaceHoldResponse, error)
+	CancelHold(ctx context.Context, req *api.CancelHoldRequest) (*api.CancelHoldResponse, error)
+	GetHold(ctx context.Context, req *api.GetHoldRequest) (*api.GetHoldResponse, error)
+	ListHolds(ctx context.Context, req *api.ListHoldsRequest) (*api.ListHoldsResponse, error)
+	ExpireHolds(ctx context.Context) (int, error)
+}
+
+type loanService struct {
+	users    api.UserServiceClient
+	repo     repository.LoanRepository
+	holdRepo repository.HoldRepository
+	logger   *logger.Log
+}
+
+func NewLoanService(ctx context.Context, registry discovery.Registry, repo repository.LoanRepository, holdRepo repository.HoldRepository, logger *logger.Log) (*loanService, error) {
+	conn, err := discovery.ServiceConnection(ctx, "user-service-grpc", registry)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to user-service: %v", err))
+
+		return nil, err
+	}
+	logger.Log(fmt.Sprintf("Connected to user-service at %s", conn.Target()))
+
+	return &loanService{
+		users:    api.NewUserServiceClient(conn),
+		repo:     repo,
+		holdRepo: holdRepo,
+		logger:   logger,
+	}, nil
+}
+
+// borrower looks the member up in user-service so loans and holds are only
+// recorded for accounts that exist. The lookup runs as the caller, who may
+// read their own account, or anyone's as staff.
+func (s *loanService) borrower(ctx context.Context, userID string) (*api.User, error) {
+	res, err := s.users.GetUser(auth.ForwardToken(ctx), &api.GetUserRequest{UserId: userID})
+	if err != nil || res.User == nil {
+		s.logger.Error(fmt.Sprintf("(RPC) Failed to get borrower %s: %v", userID, err))
+		switch err := errs.FromStatus(err); errs.KindOf(err) {
+		case errs.Unavailable:
+			return nil, errs.New(errs.Unavailable, "User Service is not available.")
+		case errs.Unauthenticated, errs.PermissionDenied:
+			return nil, err
+		}
+		return nil, errs.Newf(errs.NotFound, "borrower with ID %s not found", userID)
+	}
+	return res.User, nil
+}
+
+func (s *loanService) CheckoutCopy(ctx context.Context, req *api.CheckoutCopyRequest) (*api.CheckoutCopyResponse, error) {
+	if req.CopyId == "" || req.UserId == "" {
+		return nil, errs.New(errs.InvalidArgument, "copy_id and user_id are required")
+	}
+
+	borrower, err := s.borrower(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	loan := &api.Loan{
+		Id:           uuid.New().String(),
+		CopyId:       req.CopyId,
+		UserId:       borrower.Id,
+		CheckedOutAt: timestamppb.New(time.Now()),
+	}
+
+	res, err := s.repo.CheckoutCopy(ctx, loan)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to check out copy: %v", err))
+		return nil, err
+	}
+
+	return &api.CheckoutCopyResponse{
+		Loan: res,
+	}, nil
+}
+
+func (s *loanService) ReturnCopy(ctx context.Context, req *api.ReturnCopyRequest) (*api.ReturnCopyResponse, error) {
+	res, err := s.repo.ReturnCopy(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to return copy: %v", err))
+		return nil, err
+	}
+
+	return &api.ReturnCopyResponse{
+		Loan: res,
+	}, nil
+}
+
+func (s *loanService) RenewLoan(ctx context.Context, req *api.RenewLoanRequest) (*api.RenewLoanResponse, error) {
+	res, err := s.repo.RenewLoan(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to renew loan: %v", err))
+		return nil, err
+	}
+
+	return &api.RenewLoanResponse{
+		Loan: res,
+	}, nil
+}
+
+func (s *loanService) ListLoans(ctx context.Context, req *api.ListLoansRequest) (*api.ListLoansResponse, error) {
+	loans, err := s.repo.ListLoans(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list loans: %v", err))
+		return nil, err
+	}
+	return loans, nil
+}
+
+func (s *loanService) PlaceHold(ctx context.Context, req *api.PlaceHoldRequest) (*api.PlaceHoldResponse, error) {
+	if req.BookId == "" || req.UserId == "" {
+		return nil, errs.New(errs.InvalidArgument, "book_id and user_id are required")
+	}
+
+	borrower, err := s.borrower(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &api.Hold{
+		Id:        uuid.New().String(),
+		BookId:    req.BookId,
+		UserId:    borrower.Id,
+		CreatedAt: timestamppb.New(time.Now()),
+	}
+
+	res, err := s.holdRepo.PlaceHold(ctx, hold)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to place hold: %v", err))
+		return nil, err
+	}
+
+	return &api.PlaceHoldResponse{
+		Hold: res,
+	}, nil
+}
+
+func (s *loanService) CancelHold(ctx context.Context, req *api.CancelHoldRequest) (*api.CancelHoldResponse, error) {
+	res, err := s.holdRepo.CancelHold(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to cancel hold: %v", err))
+		return nil, err
+	}
+
+	return &api.CancelHoldResponse{
+		Hold: res,
+	}, nil
+}
+
+func (s *loanService) GetHold(ctx context.Context, req *api.GetHoldRequest) (*api.GetHoldResponse, error) {
+	res, err := s.holdRepo.GetHold(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to get hold: %v", err))
+		return nil, err
+	}
+
+	return &api.GetHoldResponse{
+		Hold: res,
+	}, nil
+}
+
+func (s *loanService) ListHolds(ctx context.Context, req *api.ListHoldsRequest) (*api.ListHoldsResponse, error) {
+	holds, err := s.holdRepo.ListHolds(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list holds: %v", err))
+		return nil, err
+	}
+	return holds, nil
+}
+
+// ExpireHolds closes ready holds that were not picked up in time and hands
+// their copies to the next member in line.
+func (s *loanService) ExpireHolds(ctx context.Context) (int, error) {
+	n, err := s.holdRepo.ExpireHolds(ctx)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to expire holds: %v", err))
+		return 0, err
+	}
+	if n > 0 {
+		s.logger.Log(fmt.Sprintf("Expired %d unclaimed hold(s)", n))
+	}
+	return n, nil
+}
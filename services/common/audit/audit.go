@@ -0,0 +1,205 @@
+// Package audit builds and stores the entries services append to their audit
+// log for every catalog change: who made it, under which request, and which
+// fields changed.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	Create  = "create"
+	Update  = "update"
+	Delete  = "delete"
+	Restore = "restore"
+)
+
+// requestIDHeader carries the request ID across gRPC calls. It matches the
+// header set by the requestid middleware.
+const requestIDHeader = "x-request-id"
+
+type requestIDKey struct{}
+
+// Middleware makes the ID assigned by the requestid middleware available to
+// RequestID. It must run after requestid.New.
+func Middleware() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		ctx.Locals(requestIDKey{}, requestid.FromContext(ctx))
+		return ctx.Next()
+	}
+}
+
+// UnaryServerInterceptor makes the request ID sent by the calling service
+// available to RequestID.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(requestIDHeader); len(values) > 0 {
+			ctx = context.WithValue(ctx, requestIDKey{}, values[0])
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RequestID returns the ID of the request being served, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ForwardRequestID returns ctx with the request ID attached as outgoing gRPC
+// metadata, so changes made downstream are logged under the same request.
+func ForwardRequestID(ctx context.Context) context.Context {
+	if id := RequestID(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, requestIDHeader, id)
+	}
+	return ctx
+}
+
+// NewEntry describes a change to an entity made by the caller in ctx. Either
+// before or after is nil for creates and deletes.
+func NewEntry(ctx context.Context, entityType, entityID, action string, before, after proto.Message) (*api.AuditEntry, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	var actorID string
+	if claims, ok := auth.FromContext(ctx); ok {
+		actorID = claims.Subject
+	}
+
+	return &api.AuditEntry{
+		Id:         uuid.New().String(),
+		EntityType: entityType,
+		EntityId:   entityID,
+		Action:     action,
+		ActorId:    actorID,
+		RequestId:  RequestID(ctx),
+		Changes:    changes,
+		CreatedAt:  timestamppb.New(time.Now()),
+	}, nil
+}
+
+// Diff lists the fields whose JSON encoding differs between before and
+// after, in field name order. A nil message has no fields.
+func Diff(before, after proto.Message) ([]*api.FieldChange, error) {
+	old, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range updated {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var changes []*api.FieldChange
+	for _, name := range names {
+		if old[name] != updated[name] {
+			changes = append(changes, &api.FieldChange{
+				Field:  name,
+				Before: old[name],
+				After:  updated[name],
+			})
+		}
+	}
+	return changes, nil
+}
+
+// fields returns the compact JSON encoding of each populated field of m.
+func fields(m proto.Message) (map[string]string, error) {
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return nil, nil
+	}
+
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		// protojson varies its whitespace, so compare compacted values.
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, value); err != nil {
+			return nil, err
+		}
+		values[name] = buf.String()
+	}
+	return values, nil
+}
+
+type change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// EncodeChanges stores changes as a JSON object keyed by field name, keeping
+// the values as JSON rather than strings.
+func EncodeChanges(changes []*api.FieldChange) ([]byte, error) {
+	object := make(map[string]change, len(changes))
+	for _, c := range changes {
+		var stored change
+		if c.Before != "" {
+			stored.Before = json.RawMessage(c.Before)
+		}
+		if c.After != "" {
+			stored.After = json.RawMessage(c.After)
+		}
+		object[c.Field] = stored
+	}
+	return json.Marshal(object)
+}
+
+// DecodeChanges reverses EncodeChanges.
+func DecodeChanges(data []byte) ([]*api.FieldChange, error) {
+	var object map[string]change
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	changes := make([]*api.FieldChange, 0, len(object))
+	for field, c := range object {
+		changes = append(changes, &api.FieldChange{
+			Field:  field,
+			Before: string(c.Before),
+			After:  string(c.After),
+		})
+	}
+	slices.SortFunc(changes, func(a, b *api.FieldChange) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return changes, nil
+}
@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/pagination"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Query stores audit entries in a service's audit_log table.
+type Query interface {
+	Record(ctx context.Context, tx pgx.Tx, entry *api.AuditEntry) error
+	ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error)
+}
+
+type auditQuery struct {
+	db *pgxpool.Pool
+}
+
+func NewQuery(db *pgxpool.Pool) *auditQuery {
+	return &auditQuery{
+		db: db,
+	}
+}
+
+const auditColumns = `id, entity_type, entity_id, action, actor_id, request_id, changes, created_at`
+
+func scanAuditEntry(row pgx.Row) (*api.AuditEntry, error) {
+	var e api.AuditEntry
+	var actorID *string
+	var changes []byte
+	var createdAt time.Time
+
+	err := row.Scan(&e.Id, &e.EntityType, &e.EntityId, &e.Action, &actorID, &e.RequestId, &changes, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorID != nil {
+		e.ActorId = *actorID
+	}
+	e.CreatedAt = timestamppb.New(createdAt)
+	e.Changes, err = DecodeChanges(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audit changes: %w", err)
+	}
+	return &e, nil
+}
+
+// Record appends entry to the audit log as part of tx, so the entry is only
+// kept if the change it describes is committed.
+func (q *auditQuery) Record(ctx context.Context, tx pgx.Tx, entry *api.AuditEntry) error {
+	changes, err := EncodeChanges(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	var actorID *string
+	if entry.ActorId != "" {
+		actorID = &entry.ActorId
+	}
+
+	query := `INSERT INTO audit_log (` + auditColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err = tx.Exec(ctx, query, entry.Id, entry.EntityType, entry.EntityId, entry.Action, actorID, entry.RequestId, changes, entry.CreatedAt.AsTime())
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", errs.DB(err))
+	}
+	return nil
+}
+
+func (q *auditQuery) ListAuditEntries(ctx context.Context, req *api.ListAuditEntriesRequest) (*api.ListAuditEntriesResponse, error) {
+	limit, offset, err := pagination.Resolve(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if req.EntityType != "" {
+		where("entity_type = $%d", req.EntityType)
+	}
+	if req.EntityId != "" {
+		where("entity_id = $%d", req.EntityId)
+	}
+	if req.ActorId != "" {
+		where("actor_id = $%d", req.ActorId)
+	}
+	if req.Since != nil {
+		where("created_at >= $%d", req.Since.AsTime())
+	}
+	if req.Until != nil {
+		where("created_at < $%d", req.Until.AsTime())
+	}
+
+	filter := ""
+	if len(conditions) > 0 {
+		filter = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := q.db.QueryRow(ctx, `SELECT COUNT(*) FROM audit_log `+filter, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count audit entries: %w", errs.DB(err))
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM audit_log %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`, auditColumns, filter, len(args)+1, len(args)+2)
+	rows, err := q.db.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", errs.DB(err))
+	}
+	defer rows.Close()
+
+	var entries []*api.AuditEntry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit entries: %w", err)
+	}
+
+	return &api.ListAuditEntriesResponse{
+		Entries: entries,
+		PageInfo: &api.PageInfo{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
+	}, nil
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/discovery"
+)
+
+const (
+	ModeLocal  = "local"
+	ModeRemote = "remote"
+)
+
+type Config struct {
+	// Mode is "local" to verify tokens in-process or "remote" to ask user-service.
+	Mode string
+	// Secret is the HS256 key; ignored when PublicKeyPath is set.
+	Secret string
+	// PublicKeyPath points at a PEM encoded RS256 public key.
+	PublicKeyPath string
+	Issuer        string
+}
+
+func NewValidator(ctx context.Context, cfg Config, registry discovery.Registry) (Validator, error) {
+	switch cfg.Mode {
+	case ModeRemote:
+		conn, err := discovery.ServiceConnection(ctx, "user-service-grpc", registry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to user-service: %w", err)
+		}
+		return NewRemoteValidator(api.NewUserServiceClient(conn)), nil
+	case ModeLocal, "":
+		if cfg.PublicKeyPath != "" {
+			key, err := os.ReadFile(cfg.PublicKeyPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read jwt public key: %w", err)
+			}
+			return NewRSAValidator(key, cfg.Issuer)
+		}
+		return NewHMACValidator(cfg.Secret, cfg.Issuer)
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", cfg.Mode)
+	}
+}
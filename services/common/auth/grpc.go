@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor authenticates callers that send an "authorization"
+// metadata entry and stores their claims on the context. Calls without one
+// pass through anonymously; the policy interceptor decides whether that is
+// acceptable for the method.
+func UnaryServerInterceptor(v Validator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor.
+func StreamServerInterceptor(v Validator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), v)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, v Validator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	token, ok := bearerToken(values[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "malformed authorization metadata")
+	}
+
+	claims, err := v.Validate(ctx, token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		return nil, status.Error(codes.Unavailable, "authentication is unavailable")
+	}
+
+	return context.WithValue(NewContext(ctx, claims), tokenKey{}, token), nil
+}
+
+// serverStream replaces the context of a stream with one carrying claims.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// UnaryServerInterceptor enforces the policy using the RPC method name as the action.
+func (p *Policy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := p.authorizeMethod(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor.
+func (p *Policy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := p.authorizeMethod(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (p *Policy) authorizeMethod(ctx context.Context, fullMethod string) error {
+	if err := p.Authorize(ctx, path.Base(fullMethod)); err != nil {
+		if errors.Is(err, ErrUnauthenticated) {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// ForwardToken returns ctx with the caller's bearer token attached as
+// outgoing gRPC metadata, so a downstream service authorizes the call as the
+// same user. Anonymous callers get ctx back unchanged.
+func ForwardToken(ctx context.Context) context.Context {
+	token, ok := ctx.Value(tokenKey{}).(string)
+	if !ok || token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
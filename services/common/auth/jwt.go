@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Claims struct {
+	jwt.Claims
+	Role string `json:"role,omitempty"`
+}
+
+// Validator checks a raw bearer token and returns the claims it carries.
+type Validator interface {
+	Validate(ctx context.Context, token string) (*Claims, error)
+}
+
+// KeyValidator verifies tokens locally against an HS256 secret or an RS256 public key.
+type KeyValidator struct {
+	alg    jose.SignatureAlgorithm
+	key    any
+	issuer string
+}
+
+func NewHMACValidator(secret, issuer string) (*KeyValidator, error) {
+	if secret == "" {
+		return nil, errors.New("jwt secret cannot be empty")
+	}
+	return &KeyValidator{alg: jose.HS256, key: []byte(secret), issuer: issuer}, nil
+}
+
+func NewRSAValidator(publicKeyPEM []byte, issuer string) (*KeyValidator, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("failed to decode jwt public key")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jwt public key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("jwt public key is not an RSA key")
+	}
+	return &KeyValidator{alg: jose.RS256, key: key, issuer: issuer}, nil
+}
+
+func (v *KeyValidator) Validate(ctx context.Context, token string) (*Claims, error) {
+	parsed, err := jwt.ParseSigned(token, []jose.SignatureAlgorithm{v.alg})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims Claims
+	if err := parsed.Claims(v.key, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if err := claims.ValidateWithLeeway(jwt.Expected{Issuer: v.issuer, Time: time.Now()}, jwt.DefaultLeeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return &claims, nil
+}
+
+// TokenManager issues tokens and validates the ones it issued.
+type TokenManager struct {
+	*KeyValidator
+
+	issuer string
+	expiry time.Duration
+	signer jose.Signer
+}
+
+func NewTokenManager(secret, issuer string, expiry time.Duration) (*TokenManager, error) {
+	validator, err := NewHMACValidator(secret, issuer)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenManager(validator, jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)}, issuer, expiry)
+}
+
+func NewRSATokenManager(privateKeyPEM []byte, issuer string, expiry time.Duration) (*TokenManager, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("failed to decode jwt private key")
+	}
+
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("jwt private key is not an RSA key")
+		}
+		key = rsaKey
+	} else if rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = rsaKey
+	} else {
+		return nil, fmt.Errorf("failed to parse jwt private key: %w", err)
+	}
+
+	validator := &KeyValidator{alg: jose.RS256, key: &key.PublicKey, issuer: issuer}
+	return newTokenManager(validator, jose.SigningKey{Algorithm: jose.RS256, Key: key}, issuer, expiry)
+}
+
+func newTokenManager(validator *KeyValidator, key jose.SigningKey, issuer string, expiry time.Duration) (*TokenManager, error) {
+	signer, err := jose.NewSigner(key, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwt signer: %w", err)
+	}
+
+	return &TokenManager{
+		KeyValidator: validator,
+		issuer:       issuer,
+		expiry:       expiry,
+		signer:       signer,
+	}, nil
+}
+
+func (m *TokenManager) Generate(userID, role string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Claims: jwt.Claims{
+			Subject:   userID,
+			Issuer:    m.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Expiry:    jwt.NewNumericDate(now.Add(m.expiry)),
+		},
+		Role: role,
+	}
+
+	token, err := jwt.Signed(m.signer).Claims(claims).Serialize()
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return token, nil
+}
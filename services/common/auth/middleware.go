@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type claimsKey struct{}
+
+type tokenKey struct{}
+
+// Middleware rejects requests without a valid bearer token and stores the
+// caller's claims on the request so handlers can read them with UserID and Role.
+func Middleware(v Validator) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		token, ok := bearerToken(ctx.Get(fiber.HeaderAuthorization))
+		if !ok {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing bearer token"})
+		}
+
+		claims, err := v.Validate(ctx.Context(), token)
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) {
+				return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired token"})
+			}
+			return ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "authentication is unavailable"})
+		}
+
+		ctx.Locals(claimsKey{}, claims)
+		ctx.Locals(tokenKey{}, token)
+		return ctx.Next()
+	}
+}
+
+func bearerToken(header string) (string, bool) {
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(header[len(prefix):])
+	return token, token != ""
+}
+
+// NewContext returns a copy of ctx carrying the caller's claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims stored by Middleware or NewContext. It also
+// works on the context returned by fiber.Ctx.Context().
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
+func UserID(ctx fiber.Ctx) string {
+	if claims, ok := ctx.Locals(claimsKey{}).(*Claims); ok {
+		return claims.Subject
+	}
+	return ""
+}
+
+func Role(ctx fiber.Ctx) string {
+	if claims, ok := ctx.Locals(claimsKey{}).(*Claims); ok {
+		return claims.Role
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleLibrarian = "librarian"
+	RoleReader    = "reader"
+)
+
+// CatalogWriters may create, update and delete books and categories.
+var CatalogWriters = []string{RoleAdmin, RoleLibrarian}
+
+var (
+	ErrUnauthenticated  = errs.New(errs.Unauthenticated, "authentication required")
+	ErrPermissionDenied = errs.New(errs.PermissionDenied, "permission denied")
+)
+
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleLibrarian || role == RoleReader
+}
+
+// Policy maps an action name to the roles allowed to perform it. Action names
+// are the RPC method names (e.g. "CreateBook") so HTTP routes and gRPC handlers
+// share one rule set. Actions without a rule are open to everyone.
+type Policy struct {
+	rules  map[string][]string
+	logger *logger.Log
+}
+
+func NewPolicy(rules map[string][]string) *Policy {
+	return &Policy{
+		rules:  rules,
+		logger: logger.New("authz"),
+	}
+}
+
+// Authorize checks the claims stored on ctx against the rule for action and
+// logs every decision it makes.
+func (p *Policy) Authorize(ctx context.Context, action string) error {
+	roles, ok := p.rules[action]
+	if !ok {
+		return nil
+	}
+
+	claims, ok := FromContext(ctx)
+	if !ok {
+		p.logger.Log(fmt.Sprintf("decision=deny action=%s user=- role=- reason=unauthenticated", action))
+		return ErrUnauthenticated
+	}
+
+	if !slices.Contains(roles, claims.Role) {
+		p.logger.Log(fmt.Sprintf("decision=deny action=%s user=%s role=%s reason=role", action, claims.Subject, claims.Role))
+		return ErrPermissionDenied
+	}
+
+	p.logger.Log(fmt.Sprintf("decision=allow action=%s user=%s role=%s", action, claims.Subject, claims.Role))
+	return nil
+}
+
+// Require returns a Fiber handler enforcing the rule for action. It must run
+// after Middleware.
+func (p *Policy) Require(action string) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		if err := p.Authorize(ctx.Context(), action); err != nil {
+			return errs.Respond(ctx, err)
+		}
+		return ctx.Next()
+	}
+}
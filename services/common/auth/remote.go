@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// RemoteValidator delegates token checks to user-service's ValidateJWT RPC.
+type RemoteValidator struct {
+	client api.UserServiceClient
+}
+
+func NewRemoteValidator(client api.UserServiceClient) *RemoteValidator {
+	return &RemoteValidator{client: client}
+}
+
+func (v *RemoteValidator) Validate(ctx context.Context, token string) (*Claims, error) {
+	res, err := v.client.ValidateJWT(ctx, &api.ValidateJWTRequest{Token: token})
+	if err != nil {
+		return nil, fmt.Errorf("(RPC) failed to validate token: %w", err)
+	}
+	if !res.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return &Claims{
+		Claims: jwt.Claims{Subject: res.UserId},
+		Role:   res.Role,
+	}, nil
+}
@@ -3,6 +3,7 @@ package consul
 import (
 	"context"
 	"fmt"
+	"time"
 
 	consul "github.com/hashicorp/consul/api"
 )
@@ -64,6 +65,20 @@ func (r *Registry) GetService(ctx context.Context, serviceName string) ([]*consu
 	return services, nil
 }
 
+// WatchService is GetService as a blocking query: it waits until the healthy
+// instances change from those seen at index, or the wait times out, and
+// returns them with the index to wait on next. An index of 0 returns at once.
+func (r *Registry) WatchService(ctx context.Context, serviceName string, index uint64) ([]*consul.ServiceEntry, uint64, error) {
+	opts := (&consul.QueryOptions{WaitIndex: index, WaitTime: 5 * time.Minute}).WithContext(ctx)
+
+	services, meta, err := r.client.Health().Service(serviceName, "", true, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to watch service: %w", err)
+	}
+
+	return services, meta.LastIndex, nil
+}
+
 func (r *Registry) HealthCheck(serviceID, serviceName string) error {
 	checkID := "service:" + serviceID
 	return r.client.Agent().UpdateTTL(checkID, "online", consul.HealthPassing)
@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/daffaromero/gobook/services/common/resilience"
 	consul "github.com/hashicorp/consul/api"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -15,6 +16,7 @@ type Registry interface {
 	RegisterService(ctx context.Context, serviceName, serviceID, serviceAddress string, servicePort int, tags []string) error
 	DeregisterService(ctx context.Context, serviceID string) error
 	GetService(ctx context.Context, serviceName string) ([]*consul.ServiceEntry, error)
+	WatchService(ctx context.Context, serviceName string, index uint64) ([]*consul.ServiceEntry, uint64, error)
 	HealthCheck(serviceID, serviceName string) error
 }
 
@@ -22,19 +24,17 @@ func GenerateServiceID(serviceName string) string {
 	return fmt.Sprintf("%s-%d", serviceName, rand.New(rand.NewSource(time.Now().UnixNano())).Int())
 }
 
+// ServiceConnection returns a connection that spreads calls round robin over
+// the healthy instances of serviceName, following them as they come and go.
+// It does not wait for an instance to be available. Calls get the deadlines,
+// retries and circuit breaker of resilience.DefaultConfig.
 func ServiceConnection(ctx context.Context, serviceName string, registry Registry) (*grpc.ClientConn, error) {
-	service, err := registry.GetService(ctx, serviceName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get service: %w", err)
-	}
-
-	if len(service) == 0 {
-		return nil, fmt.Errorf("service not found")
-	}
-
-	serviceEntry := service[rand.Intn(len(service))]
+	opts := append([]grpc.DialOption{
+		grpc.WithResolvers(NewResolverBuilder(registry)),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, resilience.DialOptions(serviceName, resilience.DefaultConfig())...)
 
-	conn, err := grpc.NewClient(fmt.Sprintf("%s:%d", serviceEntry.Service.Address, serviceEntry.Service.Port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(Scheme+":///"+serviceName, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
@@ -0,0 +1,145 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the gRPC target scheme resolved through a Registry, as in
+// "consul:///book-service-grpc".
+const Scheme = "consul"
+
+const maxResolveBackoff = 30 * time.Second
+
+// NewResolverBuilder returns a gRPC resolver for Scheme targets that keeps
+// each connection's address list in step with the healthy instances in
+// registry.
+func NewResolverBuilder(registry Registry) resolver.Builder {
+	return &resolverBuilder{registry: registry}
+}
+
+type resolverBuilder struct {
+	registry Registry
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	serviceName := target.Endpoint()
+	if serviceName == "" {
+		return nil, fmt.Errorf("%s target %q names no service", Scheme, target.URL.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &serviceResolver{
+		registry:    b.registry,
+		serviceName: serviceName,
+		cc:          cc,
+		cancel:      cancel,
+		resolveNow:  make(chan struct{}, 1),
+	}
+
+	r.wg.Add(1)
+	go r.watch(ctx)
+	return r, nil
+}
+
+type serviceResolver struct {
+	registry    Registry
+	serviceName string
+	cc          resolver.ClientConn
+	cancel      context.CancelFunc
+	resolveNow  chan struct{}
+	wg          sync.WaitGroup
+}
+
+// watch follows the service's healthy instances with blocking queries until
+// the resolver is closed.
+func (r *serviceResolver) watch(ctx context.Context) {
+	defer r.wg.Done()
+
+	var index uint64
+	backoff := time.Second
+	for {
+		err := r.resolve(ctx, &index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			backoff = time.Second
+			continue
+		}
+
+		r.cc.ReportError(err)
+		index = 0
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.resolveNow:
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxResolveBackoff)
+	}
+}
+
+// resolve waits for the instances to change from those seen at index and
+// passes them on to the connection.
+func (r *serviceResolver) resolve(ctx context.Context, index *uint64) error {
+	services, next, err := r.registry.WatchService(ctx, r.serviceName, *index)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", r.serviceName, err)
+	}
+
+	switch {
+	case next < *index:
+		// Consul indexes can go backwards, for example after a snapshot
+		// restore; start again rather than wait on one that will not be
+		// reached.
+		next = 0
+	case next == 0:
+		// Waiting on 0 would return at once and spin.
+		next = 1
+	}
+	*index = next
+
+	if len(services) == 0 {
+		r.cc.ReportError(fmt.Errorf("no healthy instances of %s", r.serviceName))
+		return nil
+	}
+	return r.cc.UpdateState(resolver.State{Addresses: addresses(services)})
+}
+
+func addresses(services []*consul.ServiceEntry) []resolver.Address {
+	addrs := make([]resolver.Address, 0, len(services))
+	for _, entry := range services {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		addrs = append(addrs, resolver.Address{Addr: net.JoinHostPort(host, strconv.Itoa(entry.Service.Port))})
+	}
+	return addrs
+}
+
+// ResolveNow cuts short the wait after a failed lookup. Changes are already
+// picked up as they happen otherwise.
+func (r *serviceResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *serviceResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
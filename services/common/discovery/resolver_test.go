@@ -0,0 +1,157 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"google.golang.org/grpc/resolver"
+)
+
+type watchResult struct {
+	services []*consul.ServiceEntry
+	index    uint64
+	err      error
+}
+
+// fakeRegistry answers each WatchService call with the next result sent on
+// results, and records the index it was called with.
+type fakeRegistry struct {
+	Registry
+
+	results chan watchResult
+	indexes chan uint64
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{results: make(chan watchResult), indexes: make(chan uint64, 16)}
+}
+
+func (r *fakeRegistry) WatchService(ctx context.Context, serviceName string, index uint64) ([]*consul.ServiceEntry, uint64, error) {
+	r.indexes <- index
+	select {
+	case res := <-r.results:
+		return res.services, res.index, res.err
+	case <-ctx.Done():
+		return nil, index, ctx.Err()
+	}
+}
+
+// fakeConn records the addresses and errors a resolver reports.
+type fakeConn struct {
+	resolver.ClientConn
+
+	addrs  chan []string
+	errors chan error
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{addrs: make(chan []string, 16), errors: make(chan error, 16)}
+}
+
+func (c *fakeConn) UpdateState(state resolver.State) error {
+	var addrs []string
+	for _, a := range state.Addresses {
+		addrs = append(addrs, a.Addr)
+	}
+	c.addrs <- addrs
+	return nil
+}
+
+func (c *fakeConn) ReportError(err error) {
+	c.errors <- err
+}
+
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the resolver")
+	}
+	panic("unreachable")
+}
+
+func entry(serviceAddress, nodeAddress string, port int) *consul.ServiceEntry {
+	return &consul.ServiceEntry{
+		Node:    &consul.Node{Address: nodeAddress},
+		Service: &consul.AgentService{Address: serviceAddress, Port: port},
+	}
+}
+
+func build(t *testing.T, registry Registry, conn resolver.ClientConn) resolver.Resolver {
+	t.Helper()
+
+	target := resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/book-service-grpc"}}
+	r, err := NewResolverBuilder(registry).Build(target, conn, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestBuildRejectsTargetWithoutService(t *testing.T) {
+	target := resolver.Target{URL: url.URL{Scheme: Scheme}}
+	if _, err := NewResolverBuilder(newFakeRegistry()).Build(target, newFakeConn(), resolver.BuildOptions{}); err == nil {
+		t.Error("Build() succeeded for a target naming no service")
+	}
+}
+
+func TestResolverFollowsInstances(t *testing.T) {
+	registry, conn := newFakeRegistry(), newFakeConn()
+	build(t, registry, conn)
+
+	if got := receive(t, registry.indexes); got != 0 {
+		t.Fatalf("first lookup at index %d, want 0", got)
+	}
+	registry.results <- watchResult{services: []*consul.ServiceEntry{entry("10.0.0.1", "node-a", 8080), entry("", "node-b", 9090)}, index: 5}
+	if got, want := receive(t, conn.addrs), []string{"10.0.0.1:8080", "node-b:9090"}; !slices.Equal(got, want) {
+		t.Errorf("addresses = %v, want %v", got, want)
+	}
+
+	// The next lookup waits for a change from the index just seen.
+	if got := receive(t, registry.indexes); got != 5 {
+		t.Fatalf("second lookup at index %d, want 5", got)
+	}
+	registry.results <- watchResult{index: 3}
+	if err := receive(t, conn.errors); err == nil {
+		t.Error("no error reported when every instance went away")
+	}
+
+	// The index went backwards, so the lookup starts over.
+	if got := receive(t, registry.indexes); got != 0 {
+		t.Errorf("lookup after the index went backwards at index %d, want 0", got)
+	}
+}
+
+func TestResolverRetriesOnResolveNow(t *testing.T) {
+	registry, conn := newFakeRegistry(), newFakeConn()
+	r := build(t, registry, conn)
+
+	receive(t, registry.indexes)
+	registry.results <- watchResult{index: 7, services: []*consul.ServiceEntry{entry("10.0.0.1", "", 8080)}}
+	receive(t, conn.addrs)
+
+	receive(t, registry.indexes)
+	registry.results <- watchResult{err: errors.New("connection refused")}
+	if err := receive(t, conn.errors); err == nil {
+		t.Fatal("lookup failure was not reported")
+	}
+
+	// Without ResolveNow the retry would wait out a one second backoff.
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	select {
+	case got := <-registry.indexes:
+		if got != 0 {
+			t.Errorf("retry at index %d, want 0", got)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("ResolveNow did not cut the backoff short")
+	}
+}
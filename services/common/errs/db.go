@@ -0,0 +1,37 @@
+package errs
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+	pgInvalidText         = "22P02"
+)
+
+// DB classifies constraint violations and malformed input reported by
+// Postgres. Other errors, including nil, are returned unchanged.
+func DB(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return Wrap(AlreadyExists, err, "a record with the same key already exists")
+	case pgForeignKeyViolation:
+		return Wrap(Conflict, err, "the record is referenced by or refers to a missing record")
+	case pgCheckViolation, pgNotNullViolation:
+		return Wrap(InvalidArgument, err, "the record failed validation")
+	case pgInvalidText:
+		return Wrap(InvalidArgument, err, "malformed identifier or value")
+	}
+	return err
+}
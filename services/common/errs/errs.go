@@ -0,0 +1,107 @@
+// Package errs classifies errors by kind so each transport can report them
+// with a matching status code. Queries and services return *Error values (or
+// wrap them with fmt.Errorf and %w); controllers and gRPC servers translate
+// them with Respond and ToStatus.
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Kind uint8
+
+const (
+	Internal Kind = iota
+	InvalidArgument
+	NotFound
+	// Conflict rejects a write that the resource's current state does not
+	// allow, such as checking out a copy that is on loan.
+	Conflict
+	PermissionDenied
+	Unauthenticated
+	Unavailable
+	// FailedPrecondition rejects a write made against a stale version of a
+	// resource.
+	FailedPrecondition
+	// AlreadyExists rejects a create whose key is already taken.
+	AlreadyExists
+)
+
+func (k Kind) String() string {
+	switch k {
+	case InvalidArgument:
+		return "invalid argument"
+	case NotFound:
+		return "not found"
+	case Conflict:
+		return "conflict"
+	case PermissionDenied:
+		return "permission denied"
+	case Unauthenticated:
+		return "unauthenticated"
+	case Unavailable:
+		return "unavailable"
+	case FailedPrecondition:
+		return "failed precondition"
+	case AlreadyExists:
+		return "already exists"
+	}
+	return "internal"
+}
+
+// Error is an error with a kind and a message that is safe to show clients.
+// Err is the underlying cause; it is logged but never sent to clients.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(kind Kind, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func Newf(kind Kind, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap classifies err, keeping it as the cause of the returned error.
+func Wrap(kind Kind, err error, message string) error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// KindOf returns the kind of the first *Error in err's chain, or Internal.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return Internal
+}
+
+// Is reports whether err has the given kind.
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// Message is the client-facing text for err. Unclassified errors may carry
+// SQL or connection details, so they are reported generically.
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return "internal server error"
+}
@@ -0,0 +1,105 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKindOfWrappedError(t *testing.T) {
+	err := fmt.Errorf("loading book: %w", New(NotFound, "book not found"))
+
+	if !Is(err, NotFound) || Is(err, Conflict) {
+		t.Errorf("KindOf(%v) = %s, want %s", err, KindOf(err), NotFound)
+	}
+	if Is(nil, Internal) {
+		t.Error("Is(nil, Internal) = true, want false")
+	}
+	if got := Message(err); got != "book not found" {
+		t.Errorf("Message() = %q, want %q", got, "book not found")
+	}
+}
+
+func TestMessageHidesUnclassifiedErrors(t *testing.T) {
+	err := errors.New(`pq: relation "books" does not exist`)
+	if got := Message(err); got != "internal server error" {
+		t.Errorf("Message() = %q, want the generic message", got)
+	}
+	if got := HTTPStatus(err); got != fiber.StatusInternalServerError {
+		t.Errorf("HTTPStatus() = %d, want %d", got, fiber.StatusInternalServerError)
+	}
+}
+
+func TestDB(t *testing.T) {
+	tests := []struct {
+		code string
+		want Kind
+	}{
+		{code: pgUniqueViolation, want: AlreadyExists},
+		{code: pgForeignKeyViolation, want: Conflict},
+		{code: pgCheckViolation, want: InvalidArgument},
+		{code: pgNotNullViolation, want: InvalidArgument},
+		{code: pgInvalidText, want: InvalidArgument},
+		{code: "40001", want: Internal},
+	}
+
+	for _, tt := range tests {
+		err := DB(fmt.Errorf("insert: %w", &pgconn.PgError{Code: tt.code}))
+		if got := KindOf(err); got != tt.want {
+			t.Errorf("DB(%s) kind = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+
+	if DB(nil) != nil {
+		t.Error("DB(nil) != nil")
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{name: "not found", err: New(NotFound, "book not found"), want: codes.NotFound},
+		{name: "stale version", err: New(FailedPrecondition, "stale"), want: codes.FailedPrecondition},
+		{name: "duplicate key", err: DB(&pgconn.PgError{Code: pgUniqueViolation}), want: codes.AlreadyExists},
+		{name: "state conflict", err: New(Conflict, "copy is on loan"), want: codes.Aborted},
+		{name: "unavailable", err: New(Unavailable, "down"), want: codes.Unavailable},
+		{name: "unclassified", err: errors.New("boom"), want: codes.Internal},
+		{name: "downstream status", err: status.Error(codes.ResourceExhausted, "slow down"), want: codes.ResourceExhausted},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), want: codes.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(ToStatus(tt.err)); got != tt.want {
+				t.Errorf("ToStatus() code = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if ToStatus(nil) != nil {
+		t.Error("ToStatus(nil) != nil")
+	}
+}
+
+// A kind survives a hop between services: the callee's ToStatus and the
+// caller's FromStatus agree.
+func TestFromStatusRoundTrip(t *testing.T) {
+	for _, kind := range []Kind{InvalidArgument, NotFound, Conflict, AlreadyExists, PermissionDenied, Unauthenticated, Unavailable, FailedPrecondition} {
+		err := FromStatus(ToStatus(New(kind, "downstream failed")))
+		if got := KindOf(err); got != kind {
+			t.Errorf("kind %s came back as %s", kind, got)
+		}
+		if got := Message(err); got != "downstream failed" {
+			t.Errorf("kind %s message = %q, want the downstream message", kind, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package errs
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var codeOf = map[Kind]codes.Code{
+	Internal:           codes.Internal,
+	InvalidArgument:    codes.InvalidArgument,
+	NotFound:           codes.NotFound,
+	Conflict:           codes.Aborted,
+	PermissionDenied:   codes.PermissionDenied,
+	Unauthenticated:    codes.Unauthenticated,
+	Unavailable:        codes.Unavailable,
+	FailedPrecondition: codes.FailedPrecondition,
+	AlreadyExists:      codes.AlreadyExists,
+}
+
+// ToStatus converts err into a gRPC status error. Errors that already carry
+// a status, such as those from interceptors or downstream calls, are kept.
+func ToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok && KindOf(err) == Internal {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codeOf[KindOf(err)], Message(err))
+}
+
+// FromStatus classifies an error returned by a gRPC client call so it can be
+// passed on to this service's own callers.
+func FromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch s.Code() {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return Wrap(InvalidArgument, err, s.Message())
+	case codes.NotFound:
+		return Wrap(NotFound, err, s.Message())
+	case codes.AlreadyExists:
+		return Wrap(AlreadyExists, err, s.Message())
+	case codes.Aborted:
+		return Wrap(Conflict, err, s.Message())
+	case codes.FailedPrecondition:
+		return Wrap(FailedPrecondition, err, s.Message())
+	case codes.PermissionDenied:
+		return Wrap(PermissionDenied, err, s.Message())
+	case codes.Unauthenticated:
+		return Wrap(Unauthenticated, err, s.Message())
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return Wrap(Unavailable, err, s.Message())
+	}
+	return err
+}
+
+// UnaryServerInterceptor converts handler errors with ToStatus. It should be
+// the first interceptor in the chain so it sees every error.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		res, err := handler(ctx, req)
+		if err != nil {
+			return nil, ToStatus(err)
+		}
+		return res, nil
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return ToStatus(handler(srv, ss))
+	}
+}
@@ -0,0 +1,29 @@
+package errs
+
+import "github.com/gofiber/fiber/v3"
+
+// HTTPStatus is the response status for err.
+func HTTPStatus(err error) int {
+	switch KindOf(err) {
+	case InvalidArgument:
+		return fiber.StatusBadRequest
+	case NotFound:
+		return fiber.StatusNotFound
+	case Conflict, AlreadyExists:
+		return fiber.StatusConflict
+	case PermissionDenied:
+		return fiber.StatusForbidden
+	case Unauthenticated:
+		return fiber.StatusUnauthorized
+	case Unavailable:
+		return fiber.StatusServiceUnavailable
+	case FailedPrecondition:
+		return fiber.StatusPreconditionFailed
+	}
+	return fiber.StatusInternalServerError
+}
+
+// Respond writes err as a JSON error body with the status for its kind.
+func Respond(ctx fiber.Ctx, err error) error {
+	return ctx.Status(HTTPStatus(err)).JSON(fiber.Map{"error": Message(err)})
+}
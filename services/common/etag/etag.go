@@ -0,0 +1,47 @@
+package etag
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type versionKey struct{}
+
+// Format returns the entity tag for a resource version.
+func Format(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// Set writes the ETag header for a resource version.
+func Set(ctx fiber.Ctx, version int64) {
+	if version > 0 {
+		ctx.Set(fiber.HeaderETag, Format(version))
+	}
+}
+
+// RequireIfMatch rejects writes that do not name the version they were based
+// on in an If-Match header, and stores that version for Version. Wildcards
+// and lists of tags are refused, since they cannot pin a single version.
+func RequireIfMatch(ctx fiber.Ctx) error {
+	header := strings.TrimSpace(ctx.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return ctx.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{"error": "If-Match header is required"})
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(header, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "If-Match must be a single ETag returned by this API"})
+	}
+
+	ctx.Locals(versionKey{}, version)
+	return ctx.Next()
+}
+
+// Version returns the version stored by RequireIfMatch, or 0 when the route
+// does not use it.
+func Version(ctx fiber.Ctx) int64 {
+	version, _ := ctx.Locals(versionKey{}).(int64)
+	return version
+}
@@ -0,0 +1,79 @@
+package etag
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	app := fiber.New()
+	app.Patch("/", func(ctx fiber.Ctx) error {
+		return ctx.SendString(strconv.FormatInt(Version(ctx), 10))
+	}, RequireIfMatch)
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "missing", wantStatus: fiber.StatusPreconditionRequired},
+		{name: "strong tag", ifMatch: Format(3), wantStatus: fiber.StatusOK, wantBody: "3"},
+		{name: "weak tag", ifMatch: `W/"3"`, wantStatus: fiber.StatusOK, wantBody: "3"},
+		{name: "wildcard", ifMatch: "*", wantStatus: fiber.StatusBadRequest},
+		{name: "list", ifMatch: `"3", "4"`, wantStatus: fiber.StatusBadRequest},
+		{name: "zero", ifMatch: `"0"`, wantStatus: fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPatch, "/", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.ifMatch)
+			}
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				body, err := io.ReadAll(res.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := string(body); got != tt.wantBody {
+					t.Errorf("version = %q, want %q", got, tt.wantBody)
+				}
+			}
+		})
+	}
+}
+
+func TestSetSkipsUnversionedResources(t *testing.T) {
+	app := fiber.New()
+	app.Get("/:version", func(ctx fiber.Ctx) error {
+		version, _ := strconv.ParseInt(ctx.Params("version"), 10, 64)
+		Set(ctx, version)
+		return nil
+	})
+
+	for version, want := range map[string]string{"0": "", "7": `"7"`} {
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/"+version, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if got := res.Header.Get(fiber.HeaderETag); got != want {
+			t.Errorf("ETag for version %s = %q, want %q", version, got, want)
+		}
+	}
+}
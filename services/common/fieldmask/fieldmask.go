@@ -0,0 +1,51 @@
+package fieldmask
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/daffaromero/gobook/services/common/errs"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Resolve returns the paths of mask with duplicates removed, rejecting any
+// path that is not one of allowed.
+func Resolve(mask *fieldmaskpb.FieldMask, allowed ...string) ([]string, error) {
+	var paths []string
+	for _, path := range mask.GetPaths() {
+		path = strings.TrimSpace(path)
+		if !slices.Contains(allowed, path) {
+			return nil, errs.Newf(errs.InvalidArgument, "invalid update mask: field %q cannot be updated", path)
+		}
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// FromBody builds a mask from the fields present in the JSON object stored
+// under key in body, so a PATCH touches exactly what the client sent,
+// including fields explicitly set to empty values.
+func FromBody(body []byte, key string) (*fieldmaskpb.FieldMask, error) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, errs.Wrap(errs.InvalidArgument, err, "invalid request body")
+	}
+
+	var fields map[string]json.RawMessage
+	if raw, ok := envelope[key]; ok {
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, errs.Wrap(errs.InvalidArgument, err, "invalid request body")
+		}
+	}
+
+	mask := &fieldmaskpb.FieldMask{}
+	for field := range fields {
+		mask.Paths = append(mask.Paths, field)
+	}
+	// Map order is random; keep masks stable for logs and queries.
+	slices.Sort(mask.Paths)
+	return mask, nil
+}
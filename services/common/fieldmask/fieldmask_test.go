@@ -0,0 +1,61 @@
+package fieldmask
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/daffaromero/gobook/services/common/errs"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestResolve(t *testing.T) {
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"title", " author ", "title"}}
+	paths, err := Resolve(mask, "title", "author", "description")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"title", "author"}; !slices.Equal(paths, want) {
+		t.Errorf("Resolve() = %v, want %v", paths, want)
+	}
+
+	if _, err := Resolve(&fieldmaskpb.FieldMask{Paths: []string{"version"}}, "title"); !errs.Is(err, errs.InvalidArgument) {
+		t.Errorf("Resolve() with a disallowed path error = %v, want invalid argument", err)
+	}
+
+	if paths, err := Resolve(nil, "title"); err != nil || len(paths) != 0 {
+		t.Errorf("Resolve(nil) = %v, %v, want no paths", paths, err)
+	}
+}
+
+func TestFromBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "fields sent", body: `{"book":{"title":"Dune","author":"Frank Herbert"}}`, want: []string{"author", "title"}},
+		{name: "empty values count", body: `{"book":{"description":"","category_id":null}}`, want: []string{"category_id", "description"}},
+		{name: "no object", body: `{}`},
+		{name: "not JSON", body: `title=Dune`, wantErr: true},
+		{name: "object is not an object", body: `{"book":"Dune"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mask, err := FromBody([]byte(tt.body), "book")
+			if tt.wantErr {
+				if !errs.Is(err, errs.InvalidArgument) {
+					t.Fatalf("FromBody() error = %v, want invalid argument", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(mask.Paths, tt.want) {
+				t.Errorf("FromBody() = %v, want %v", mask.Paths, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// feedBatchSize is how many events a watcher reads from the outbox at a time.
+const feedBatchSize = 100
+
+// Feed lets callers follow the events the relay has sequenced, in sequence
+// order. Each watcher reads the outbox itself and only reads the next batch
+// once the previous one has been sent, so a slow consumer holds back its own
+// stream and nothing else.
+type Feed struct {
+	db     *pgxpool.Pool
+	logger *logger.Log
+
+	mu      sync.Mutex
+	head    int64
+	changed chan struct{}
+}
+
+func NewFeed(db *pgxpool.Pool, logger *logger.Log) *Feed {
+	return &Feed{
+		db:      db,
+		logger:  logger,
+		changed: make(chan struct{}),
+	}
+}
+
+// Run checks for new events every interval until ctx is cancelled, waking
+// watchers that have caught up when there are some.
+func (f *Feed) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var head int64
+			if err := f.db.QueryRow(ctx, `SELECT COALESCE(max(sequence), 0) FROM outbox`).Scan(&head); err != nil {
+				f.logger.Error(fmt.Sprintf("Failed to poll outbox: %v", err))
+				continue
+			}
+
+			f.mu.Lock()
+			if head > f.head {
+				f.head = head
+				close(f.changed)
+				f.changed = make(chan struct{})
+			}
+			f.mu.Unlock()
+		}
+	}
+}
+
+// wait returns a channel that is closed once the feed has seen an event
+// after sequence.
+func (f *Feed) wait(after int64) <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.head > after {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	return f.changed
+}
+
+// FromNext is the sequence to watch after for only the events sequenced from
+// now on.
+const FromNext = -1
+
+// Watch calls send with every event after the given sequence, then with new
+// ones as they are sequenced, until ctx is cancelled or send fails. An after
+// of 0 starts at the oldest retained event and FromNext at the next one;
+// otherwise that event must still be retained, or events after it may have
+// been pruned.
+func (f *Feed) Watch(ctx context.Context, after int64, send func(Event) error) error {
+	switch {
+	case after < FromNext:
+		return errs.New(errs.InvalidArgument, "sequence cannot be less than -1")
+	case after == FromNext:
+		if err := f.db.QueryRow(ctx, `SELECT COALESCE(max(sequence), 0) FROM outbox`).Scan(&after); err != nil {
+			return fmt.Errorf("failed to find the latest sequence: %w", errs.DB(err))
+		}
+	case after > 0:
+		var retained bool
+		if err := f.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM outbox WHERE sequence = $1)`, after).Scan(&retained); err != nil {
+			return fmt.Errorf("failed to check resume point: %w", errs.DB(err))
+		}
+		if !retained {
+			return errs.Newf(errs.FailedPrecondition, "cannot resume after sequence %d; start again from 0", after)
+		}
+	}
+
+	cursor := after
+	for {
+		events, err := f.read(ctx, cursor)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			if err := send(e); err != nil {
+				return err
+			}
+			cursor = e.Sequence
+		}
+		if len(events) == feedBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-f.wait(cursor):
+		}
+	}
+}
+
+func (f *Feed) read(ctx context.Context, after int64) ([]Event, error) {
+	query := `SELECT id, sequence, topic, aggregate_id, request_id, payload, created_at
+		FROM outbox
+		WHERE sequence > $1
+		ORDER BY sequence
+		LIMIT $2`
+
+	rows, err := f.db.Query(ctx, query, after, feedBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", errs.DB(err))
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Sequence, &e.Topic, &e.AggregateID, &e.RequestID, &payload, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,91 @@
+// Package outbox publishes domain events with the transactional outbox
+// pattern. Repositories write events with Enqueue in the same transaction as
+// the change they describe; a Relay later hands committed events to a
+// Publisher. Delivery is at least once: consumers should use Event.ID as an
+// idempotency key.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/audit"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Event is a change to an aggregate, such as a book, as sent to consumers.
+type Event struct {
+	// ID is unique per event and stays the same across redeliveries.
+	ID string `json:"id"`
+	// Sequence orders the events of a service. The relay assigns it when it
+	// first picks the event up.
+	Sequence    int64           `json:"sequence,omitempty"`
+	Topic       string          `json:"topic"`
+	AggregateID string          `json:"aggregate_id"`
+	RequestID   string          `json:"request_id,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+var pastTense = map[string]string{
+	audit.Create:  "created",
+	audit.Update:  "updated",
+	audit.Delete:  "deleted",
+	audit.Restore: "restored",
+}
+
+// Topic names the event for an audit action on an entity type, such as
+// "book.created".
+func Topic(entityType, action string) string {
+	return entityType + "." + pastTense[action]
+}
+
+var changeTypes = map[string]api.ChangeType{
+	"created":  api.ChangeType_CHANGE_TYPE_CREATED,
+	"updated":  api.ChangeType_CHANGE_TYPE_UPDATED,
+	"deleted":  api.ChangeType_CHANGE_TYPE_DELETED,
+	"restored": api.ChangeType_CHANGE_TYPE_RESTORED,
+}
+
+// ChangeType returns the kind of change the event's topic describes.
+func (e Event) ChangeType() api.ChangeType {
+	_, tense, _ := strings.Cut(e.Topic, ".")
+	return changeTypes[tense]
+}
+
+// NewEvent builds an event carrying payload as JSON, tagged with the request
+// being served in ctx.
+func NewEvent(ctx context.Context, topic, aggregateID string, payload proto.Message) (Event, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode event payload: %w", err)
+	}
+
+	return Event{
+		ID:          uuid.New().String(),
+		Topic:       topic,
+		AggregateID: aggregateID,
+		RequestID:   audit.RequestID(ctx),
+		Payload:     data,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// Enqueue stores event as part of tx, so it is only published if tx commits.
+func Enqueue(ctx context.Context, tx pgx.Tx, event Event) error {
+	query := `INSERT INTO outbox (id, topic, aggregate_id, request_id, payload, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := tx.Exec(ctx, query, event.ID, event.Topic, event.AggregateID, event.RequestID, []byte(event.Payload), event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue event: %w", errs.DB(err))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+)
+
+// Publisher delivers events to consumers. An error leaves the event in the
+// outbox to be retried, so Publish may see the same event more than once.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NewPublisher returns the publisher named by kind: "log" (the default),
+// "memory", "webhook" or "nats". Webhooks are sent to url, and the NATS
+// publisher connects to the servers listed in url. There is no Kafka publisher.
+func NewPublisher(kind, url string, logger *logger.Log) (Publisher, error) {
+	switch kind {
+	case "", "log":
+		return &LogPublisher{logger: logger}, nil
+	case "memory":
+		return NewMemoryPublisher(), nil
+	case "webhook":
+		if url == "" {
+			return nil, fmt.Errorf("webhook publisher requires a URL")
+		}
+		return NewWebhookPublisher(url), nil
+	case "nats":
+		if url == "" {
+			return nil, fmt.Errorf("nats publisher requires a URL")
+		}
+		return NewNATSPublisher(url)
+	}
+	return nil, fmt.Errorf("unknown outbox publisher %q", kind)
+}
+
+// LogPublisher writes events to the service log. It is meant for development,
+// where nothing consumes the events.
+type LogPublisher struct {
+	logger *logger.Log
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event Event) error {
+	p.logger.Log(fmt.Sprintf("Published %s event %s for %s", event.Topic, event.ID, event.AggregateID))
+	return nil
+}
+
+// MemoryPublisher keeps events in memory and passes them to subscribers. It
+// stands in for a message broker in tests and local runs.
+type MemoryPublisher struct {
+	mu          sync.Mutex
+	events      []Event
+	subscribers []chan Event
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	p.events = append(p.events, event)
+	subscribers := p.subscribers
+	p.mu.Unlock()
+
+	for _, ch := range subscribers {
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel that receives every event published from now
+// on. Publish blocks while the channel's buffer is full.
+func (p *MemoryPublisher) Subscribe(buffer int) <-chan Event {
+	ch := make(chan Event, buffer)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscribers = append(p.subscribers, ch)
+	return ch
+}
+
+// Events returns every event published so far.
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
+
+// WebhookPublisher POSTs each event as JSON to a URL, with the event ID in
+// the Idempotency-Key header. Any status other than 2xx is a failure.
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", event.ID)
+	req.Header.Set("X-Event-Topic", event.Topic)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", res.Status)
+	}
+	return nil
+}
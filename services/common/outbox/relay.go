@@ -0,0 +1,180 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// relayLockID is the advisory lock that keeps relays in different
+	// replicas of a service from publishing the same events.
+	relayLockID = 7_046_510_231
+
+	maxBackoff = 5 * time.Minute
+)
+
+// Relay moves committed events from the outbox to a Publisher, in the order
+// they were written for each aggregate. Events that fail are retried with
+// exponential backoff, and later events for the same aggregate wait for them.
+type Relay struct {
+	db        *pgxpool.Pool
+	publisher Publisher
+	logger    *logger.Log
+	batchSize int
+	retention time.Duration
+}
+
+// NewRelay returns a relay that publishes up to batchSize events per flush
+// and deletes published events older than retention.
+func NewRelay(db *pgxpool.Pool, publisher Publisher, logger *logger.Log, batchSize int, retention time.Duration) *Relay {
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		logger:    logger,
+		batchSize: batchSize,
+		retention: retention,
+	}
+}
+
+// Run flushes the outbox every interval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Flush(ctx); err != nil {
+				r.logger.Error(fmt.Sprintf("Failed to flush outbox: %v", err))
+			}
+		}
+	}
+}
+
+type pending struct {
+	Event
+	attempts int
+}
+
+// Flush publishes one batch of due events and returns how many were
+// published. It does nothing while another relay holds the outbox.
+//
+// The relay holds the outbox with a session advisory lock on a connection of
+// its own, and runs no transaction while it publishes, so a slow publisher
+// keeps neither row locks nor a transaction open. Each event is sequenced,
+// published and then marked as sent or rescheduled, one statement at a time;
+// a crash between publishing and marking only means the event is published
+// again.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, relayLockID).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, relayLockID); err != nil {
+			// Closing the connection is the only other way to drop the lock.
+			r.logger.Error(fmt.Sprintf("Failed to unlock outbox: %v", err))
+			conn.Conn().Close(context.WithoutCancel(ctx))
+		}
+	}()
+
+	events, err := r.due(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	blocked := make(map[string]bool)
+	for _, e := range events {
+		if blocked[e.AggregateID] {
+			continue
+		}
+
+		if e.Sequence == 0 {
+			err := conn.QueryRow(ctx, `UPDATE outbox SET sequence = nextval('outbox_sequence') WHERE id = $1 RETURNING sequence`, e.ID).Scan(&e.Sequence)
+			if err != nil {
+				return published, fmt.Errorf("failed to sequence event: %w", err)
+			}
+		}
+
+		if err := r.publisher.Publish(ctx, e.Event); err != nil {
+			blocked[e.AggregateID] = true
+			r.logger.Error(fmt.Sprintf("Failed to publish %s event %s: %v", e.Topic, e.ID, err))
+
+			backoff := min(time.Duration(1<<min(e.attempts, 16))*time.Second, maxBackoff)
+			_, err = conn.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = now() + make_interval(secs => $3) WHERE id = $1`, e.ID, err.Error(), backoff.Seconds())
+			if err != nil {
+				return published, fmt.Errorf("failed to reschedule event: %w", err)
+			}
+			continue
+		}
+
+		if _, err := conn.Exec(ctx, `UPDATE outbox SET published_at = now(), last_error = NULL WHERE id = $1`, e.ID); err != nil {
+			return published, fmt.Errorf("failed to mark event published: %w", err)
+		}
+		published++
+	}
+
+	// Only prune a prefix of the sequence, so that a watcher whose last event
+	// is still retained has not missed anything after it.
+	prune := `DELETE FROM outbox WHERE sequence <= (
+			SELECT max(sequence) FROM outbox
+			WHERE published_at < now() - make_interval(secs => $1)
+			AND sequence < COALESCE((SELECT min(sequence) FROM outbox WHERE published_at IS NULL), 9223372036854775807)
+		)`
+	if _, err := conn.Exec(ctx, prune, r.retention.Seconds()); err != nil {
+		return published, fmt.Errorf("failed to prune outbox: %w", err)
+	}
+	return published, nil
+}
+
+// due returns unpublished events that are ready to send, skipping aggregates
+// whose earliest pending event is still backing off.
+func (r *Relay) due(ctx context.Context, conn *pgxpool.Conn) ([]pending, error) {
+	query := `SELECT id, COALESCE(sequence, 0), topic, aggregate_id, request_id, payload, created_at, attempts
+		FROM outbox o
+		WHERE published_at IS NULL AND next_attempt_at <= now()
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox w
+			WHERE w.aggregate_id = o.aggregate_id AND w.published_at IS NULL AND w.next_attempt_at > now()
+			AND (w.created_at, w.id) < (o.created_at, o.id)
+		)
+		ORDER BY created_at, id
+		LIMIT $1`
+
+	rows, err := conn.Query(ctx, query, r.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var events []pending
+	for rows.Next() {
+		var e pending
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Sequence, &e.Topic, &e.AggregateID, &e.RequestID, &payload, &e.CreatedAt, &e.attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	return events, nil
+}
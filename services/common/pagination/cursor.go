@@ -0,0 +1,85 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/errs"
+)
+
+var ErrInvalidCursor = errs.New(errs.InvalidArgument, "invalid page token")
+
+// Cursor is the decoded form of a page token. It records the sort the page
+// was produced with and the sort key and id of the last row returned, so the
+// next page can continue with a keyset condition instead of an offset.
+type Cursor struct {
+	OrderBy  string `json:"o"`
+	Reversed bool   `json:"r"`
+	Value    string `json:"v"`
+	ID       string `json:"i"`
+}
+
+// NewCursor builds the cursor for the row with the given sort key and id.
+// Timestamps are encoded with nanosecond precision so no rows are skipped.
+func NewCursor(orderBy string, reversed bool, value any, id string) *Cursor {
+	c := &Cursor{OrderBy: orderBy, Reversed: reversed, ID: id}
+
+	switch v := value.(type) {
+	case time.Time:
+		c.Value = v.UTC().Format(time.RFC3339Nano)
+	case string:
+		c.Value = v
+	default:
+		c.Value = fmt.Sprint(v)
+	}
+
+	return c
+}
+
+// Encode returns the opaque token handed to clients.
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Time parses the cursor value of a timestamp sort column.
+func (c *Cursor) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+// DecodeCursor parses a token produced by Encode.
+func DecodeCursor(token string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// Matches reports whether the cursor was issued for the same sort.
+// Continuing a cursor under a different sort would skip or repeat rows.
+func (c *Cursor) Matches(orderBy string, reversed bool) bool {
+	return c.OrderBy == orderBy && c.Reversed == reversed
+}
+
+// After returns the keyset condition selecting rows that sort after the
+// cursor on column, using placeholders $n and $n+1 for the key and id.
+func (c *Cursor) After(column string, n int) string {
+	op := ">"
+	if c.Reversed {
+		op = "<"
+	}
+	return fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, op, n, n+1)
+}
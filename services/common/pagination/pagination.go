@@ -0,0 +1,112 @@
+package pagination
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Query is the query-string form of a list request:
+// ?page=2&limit=20&order_by=title&order=desc&search=tolkien
+type Query struct {
+	Page    int32  `query:"page"`
+	Limit   int32  `query:"limit"`
+	Offset  int32  `query:"offset"`
+	OrderBy string `query:"order_by"`
+	Order   string `query:"order"`
+	Search  string `query:"search"`
+}
+
+// FromQuery binds the list query parameters of an HTTP request.
+func FromQuery(ctx fiber.Ctx) (*api.Pagination, *api.Sorting, string, error) {
+	var q Query
+	if err := ctx.Bind().Query(&q); err != nil {
+		return nil, nil, "", err
+	}
+
+	order := strings.ToLower(q.Order)
+	if order != "" && order != "asc" && order != "desc" {
+		return nil, nil, "", errs.New(errs.InvalidArgument, "invalid sort: order must be asc or desc")
+	}
+
+	return &api.Pagination{Page: q.Page, Limit: q.Limit, Offset: q.Offset},
+		&api.Sorting{OrderBy: q.OrderBy, IsReversed: order == "desc"},
+		strings.TrimSpace(q.Search),
+		nil
+}
+
+// Resolve turns a Pagination message into a bounded limit and offset. An
+// explicit offset wins over page; page numbers start at 1. A negative page or
+// offset, or a page whose offset does not fit in an int32, is an
+// InvalidArgument error.
+func Resolve(p *api.Pagination) (limit, offset int32, err error) {
+	limit = DefaultLimit
+	if p == nil {
+		return limit, 0, nil
+	}
+
+	if p.Limit > 0 {
+		limit = min(p.Limit, MaxLimit)
+	}
+	if p.Page < 0 || p.Offset < 0 {
+		return 0, 0, errs.New(errs.InvalidArgument, "invalid pagination: page and offset cannot be negative")
+	}
+
+	switch {
+	case p.Offset > 0:
+		offset = p.Offset
+	case p.Page > 1:
+		if int64(p.Page-1)*int64(limit) > math.MaxInt32 {
+			return 0, 0, errs.Newf(errs.InvalidArgument, "invalid pagination: page cannot be more than %d at limit %d", math.MaxInt32/limit+1, limit)
+		}
+		offset = (p.Page - 1) * limit
+	}
+
+	return limit, offset, nil
+}
+
+// SortColumn resolves the requested sort key against the whitelisted
+// columns, falling back to defaultColumn when no sort is requested.
+func SortColumn(s *api.Sorting, columns map[string]string, defaultColumn string) (string, error) {
+	if s == nil || s.OrderBy == "" {
+		return defaultColumn, nil
+	}
+
+	column, ok := columns[s.OrderBy]
+	if !ok {
+		return "", errs.Newf(errs.InvalidArgument, "invalid sort: cannot sort by %q", s.OrderBy)
+	}
+	return column, nil
+}
+
+// OrderBy returns an ORDER BY expression for s using only the whitelisted
+// columns. The id is appended as a tiebreaker so pages are stable.
+func OrderBy(s *api.Sorting, columns map[string]string, defaultColumn string) (string, error) {
+	column, err := SortColumn(s, columns, defaultColumn)
+	if err != nil {
+		return "", err
+	}
+
+	direction := "ASC"
+	if s != nil && s.IsReversed {
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction), nil
+}
+
+// SearchPattern builds a case-insensitive ILIKE pattern that matches term
+// anywhere, with LIKE wildcards in term escaped.
+func SearchPattern(term string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(term) + "%"
+}
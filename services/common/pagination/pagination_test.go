@@ -0,0 +1,122 @@
+package pagination
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name       string
+		p          *api.Pagination
+		wantLimit  int32
+		wantOffset int32
+		wantErr    bool
+	}{
+		{name: "no pagination", wantLimit: DefaultLimit},
+		{name: "first page", p: &api.Pagination{Page: 1, Limit: 10}, wantLimit: 10},
+		{name: "later page", p: &api.Pagination{Page: 3, Limit: 10}, wantLimit: 10, wantOffset: 20},
+		{name: "offset wins over page", p: &api.Pagination{Page: 3, Limit: 10, Offset: 5}, wantLimit: 10, wantOffset: 5},
+		{name: "limit is capped", p: &api.Pagination{Limit: MaxLimit + 1}, wantLimit: MaxLimit},
+		{name: "negative page", p: &api.Pagination{Page: -1}, wantErr: true},
+		{name: "negative offset", p: &api.Pagination{Offset: -1}, wantErr: true},
+		{name: "page past int32", p: &api.Pagination{Page: math.MaxInt32, Limit: MaxLimit}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, offset, err := Resolve(tt.p)
+			if tt.wantErr {
+				if !errs.Is(err, errs.InvalidArgument) {
+					t.Fatalf("Resolve() error = %v, want invalid argument", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if limit != tt.wantLimit || offset != tt.wantOffset {
+				t.Errorf("Resolve() = %d, %d, want %d, %d", limit, offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	columns := map[string]string{"title": "title", "created_at": "created_at"}
+
+	tests := []struct {
+		name    string
+		s       *api.Sorting
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "created_at ASC, id ASC"},
+		{name: "reversed", s: &api.Sorting{OrderBy: "title", IsReversed: true}, want: "title DESC, id DESC"},
+		{name: "unknown column", s: &api.Sorting{OrderBy: "title; DROP TABLE books"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OrderBy(tt.s, columns, "created_at")
+			if tt.wantErr {
+				if !errs.Is(err, errs.InvalidArgument) {
+					t.Fatalf("OrderBy() error = %v, want invalid argument", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("OrderBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchPatternEscapesWildcards(t *testing.T) {
+	if got, want := SearchPattern(`50%_off\`), `%50\%\_off\\%`; got != want {
+		t.Errorf("SearchPattern() = %q, want %q", got, want)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.FixedZone("WIB", 7*60*60))
+	token := NewCursor("created_at", true, at, "book-1").Encode()
+
+	c, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Matches("created_at", true) || c.Matches("created_at", false) || c.Matches("title", true) {
+		t.Errorf("cursor %+v matches the wrong sorts", c)
+	}
+	if c.ID != "book-1" {
+		t.Errorf("ID = %q, want book-1", c.ID)
+	}
+
+	got, err := c.Time()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(at) {
+		t.Errorf("Time() = %v, want %v", got, at)
+	}
+
+	if got, want := c.After("created_at", 3), "(created_at, id) < ($3, $4)"; got != want {
+		t.Errorf("After() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeCursorRejectsBadTokens(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "bm90IGpzb24", NewCursor("title", false, "Dune", "").Encode()} {
+		if _, err := DecodeCursor(token); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want %v", token, err, ErrInvalidCursor)
+		}
+	}
+}
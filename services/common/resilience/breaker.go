@@ -0,0 +1,187 @@
+package resilience
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type State int
+
+const (
+	// Closed lets every call through.
+	Closed State = iota
+	// Open fails calls at once, without contacting the service.
+	Open
+	// HalfOpen lets a few probe calls through to see whether the service
+	// has recovered.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is how many calls in a row must fail before the
+	// breaker opens.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before probing.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is how many probe calls may be in flight at once.
+	HalfOpenProbes int
+}
+
+// breakerStats is published at /debug/vars, keyed by service name.
+var breakerStats = expvar.NewMap("grpc_client_breakers")
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*Breaker{}
+)
+
+// Breaker is a circuit breaker for the calls made to one service. Only
+// failures that suggest the service is down or overloaded count towards
+// opening it; errors about the request itself do not.
+type Breaker struct {
+	name   string
+	config BreakerConfig
+	logger *logger.Log
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probes   int
+
+	stateVar expvar.String
+	trips    expvar.Int
+	rejected expvar.Int
+}
+
+// BreakerFor returns the breaker for the named service, creating it with
+// config on first use. Connections to the same service share a breaker.
+func BreakerFor(name string, config BreakerConfig) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	if b, ok := breakers[name]; ok {
+		return b
+	}
+
+	b := &Breaker{
+		name:   name,
+		config: config,
+		logger: logger.New("breaker"),
+	}
+	b.stateVar.Set(Closed.String())
+
+	stats := new(expvar.Map).Init()
+	stats.Set("state", &b.stateVar)
+	stats.Set("trips", &b.trips)
+	stats.Set("rejected", &b.rejected)
+	breakerStats.Set(name, stats)
+
+	breakers[name] = b
+	return b
+}
+
+// State returns the breaker's current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may go ahead, returning an Unavailable error
+// if not. Every allowed call must be followed by Done.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open && time.Since(b.openedAt) >= b.config.OpenTimeout {
+		b.setState(HalfOpen)
+	}
+
+	switch {
+	case b.state == Closed:
+		return nil
+	case b.state == HalfOpen && b.probes < b.config.HalfOpenProbes:
+		b.probes++
+		return nil
+	}
+
+	b.rejected.Add(1)
+	return status.Errorf(codes.Unavailable, "%s is unavailable: circuit breaker is %s", b.name, b.state)
+}
+
+// Done records the outcome of a call that Allow let through.
+func (b *Breaker) Done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen && b.probes > 0 {
+		b.probes--
+	}
+
+	switch {
+	case countsAsFailure(err):
+		b.failures++
+		if b.state == HalfOpen || (b.state == Closed && b.failures >= b.config.FailureThreshold) {
+			b.trips.Add(1)
+			b.openedAt = time.Now()
+			b.setState(Open)
+		}
+	case status.Code(err) == codes.Canceled:
+		// The caller gave up; this says nothing about the service.
+	default:
+		b.failures = 0
+		if b.state == HalfOpen {
+			b.setState(Closed)
+		}
+	}
+}
+
+// setState moves the breaker to state. b.mu must be held.
+func (b *Breaker) setState(state State) {
+	if state == b.state {
+		return
+	}
+
+	message := fmt.Sprintf("Circuit breaker for %s is now %s (was %s)", b.name, state, b.state)
+	if state == Open {
+		b.logger.Error(fmt.Sprintf("%s after %d failure(s) in a row", message, b.failures))
+	} else {
+		b.logger.Log(message)
+	}
+
+	b.state = state
+	b.probes = 0
+	if state == Closed {
+		b.failures = 0
+	}
+	b.stateVar.Set(state.String())
+}
+
+// countsAsFailure reports whether err suggests the service is down or
+// overloaded, rather than that the request was wrong.
+func countsAsFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	unavailable = status.Error(codes.Unavailable, "connection refused")
+	notFound    = status.Error(codes.NotFound, "book not found")
+)
+
+// newBreaker returns a breaker of its own, since BreakerFor shares them by
+// name.
+func newBreaker(t *testing.T, config BreakerConfig) *Breaker {
+	t.Helper()
+	return BreakerFor(t.Name()+"-"+uuid.New().String(), config)
+}
+
+// call runs one call through b that fails with err, and reports whether b let
+// it through.
+func call(b *Breaker, err error) bool {
+	if b.Allow() != nil {
+		return false
+	}
+	b.Done(err)
+	return true
+}
+
+func TestBreakerFor(t *testing.T) {
+	config := BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenProbes: 1}
+	name := t.Name() + "-" + uuid.New().String()
+
+	if BreakerFor(name, config) != BreakerFor(name, config) {
+		t.Error("BreakerFor returned two breakers for one service")
+	}
+	if BreakerFor(name, config) == BreakerFor(name+"-other", config) {
+		t.Error("BreakerFor shared a breaker between two services")
+	}
+}
+
+func TestBreakerOpensAfterFailuresInARow(t *testing.T) {
+	b := newBreaker(t, BreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenProbes: 1})
+
+	call(b, unavailable)
+	call(b, unavailable)
+	call(b, nil) // A success resets the count.
+	call(b, unavailable)
+	call(b, unavailable)
+	if b.State() != Closed {
+		t.Fatalf("state = %s after two failures in a row, want %s", b.State(), Closed)
+	}
+
+	call(b, unavailable)
+	if b.State() != Open {
+		t.Fatalf("state = %s after three failures in a row, want %s", b.State(), Open)
+	}
+
+	err := b.Allow()
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Allow() on an open breaker = %v, want Unavailable", err)
+	}
+}
+
+func TestBreakerIgnoresRequestErrors(t *testing.T) {
+	b := newBreaker(t, BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenProbes: 1})
+
+	for _, err := range []error{notFound, status.Error(codes.InvalidArgument, "bad id"), status.Error(codes.Canceled, "caller left")} {
+		call(b, err)
+	}
+	if b.State() != Closed {
+		t.Errorf("state = %s, want %s", b.State(), Closed)
+	}
+}
+
+func TestBreakerProbesAfterTimeout(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe error
+		want  State
+	}{
+		{name: "service recovered", probe: notFound, want: Closed},
+		{name: "service still down", probe: status.Error(codes.DeadlineExceeded, "timeout"), want: Open},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(t, BreakerConfig{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond, HalfOpenProbes: 1})
+			call(b, unavailable)
+			time.Sleep(20 * time.Millisecond)
+
+			if err := b.Allow(); err != nil {
+				t.Fatalf("Allow() after the open timeout = %v, want a probe", err)
+			}
+			if b.State() != HalfOpen {
+				t.Fatalf("state = %s while probing, want %s", b.State(), HalfOpen)
+			}
+			if b.Allow() == nil {
+				t.Fatal("Allow() let a second probe through, want one at a time")
+			}
+
+			b.Done(tt.probe)
+			if b.State() != tt.want {
+				t.Errorf("state = %s after the probe, want %s", b.State(), tt.want)
+			}
+		})
+	}
+}
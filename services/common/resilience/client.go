@@ -0,0 +1,168 @@
+// Package resilience protects calls between services: every call gets a
+// deadline, idempotent calls are retried with jittered backoff, and a
+// circuit breaker per service fails calls fast while that service is down.
+package resilience
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type Config struct {
+	// Timeout bounds calls whose context has no earlier deadline.
+	Timeout time.Duration
+	// Timeouts overrides Timeout by full method name, such as
+	// "/BookCategoryService/GetCategory".
+	Timeouts map[string]time.Duration
+
+	// MaxAttempts is how many times an idempotent call is tried, counting
+	// the first. Each retry waits a random time up to a backoff that starts
+	// at InitialBackoff and doubles up to MaxBackoff.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	Breaker BreakerConfig
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:        5 * time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      10 * time.Second,
+			HalfOpenProbes:   1,
+		},
+	}
+}
+
+// Idempotent lists, by service, the methods that are safe to retry because
+// they change nothing.
+var Idempotent = map[string][]string{
+	"BookService": {
+		"GetBook", "ListBooks", "SearchBooks", "ListCopies", "ListDeletedBooks",
+		"CountBooksByCategory", "ListAuditEntries",
+	},
+	"LoanService": {
+		"ListLoans", "GetHold", "ListHolds", "GetBalance", "ListLedger",
+	},
+	"BookCategoryService": {
+		"GetCategory", "ListCategories", "ListDeletedCategories", "ListAuditEntries",
+	},
+	"UserService": {
+		"GetUser", "ListUsers", "ValidateJWT",
+	},
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	RetryPolicy retryPolicy  `json:"retryPolicy"`
+}
+
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	MethodConfig        []methodConfig        `json:"methodConfig"`
+	RetryThrottling     map[string]float64    `json:"retryThrottling"`
+}
+
+// ServiceConfig returns the gRPC service config for a connection: round
+// robin balancing, and retries of Idempotent methods on UNAVAILABLE. gRPC
+// jitters the backoff itself, and throttles retries while most calls fail.
+func ServiceConfig(config Config) string {
+	services := make([]string, 0, len(Idempotent))
+	for service := range Idempotent {
+		services = append(services, service)
+	}
+	slices.Sort(services)
+
+	var names []methodName
+	for _, service := range services {
+		for _, method := range Idempotent[service] {
+			names = append(names, methodName{Service: service, Method: method})
+		}
+	}
+
+	sc := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{"round_robin": {}}},
+		MethodConfig: []methodConfig{{
+			Name: names,
+			RetryPolicy: retryPolicy{
+				MaxAttempts:          config.MaxAttempts,
+				InitialBackoff:       seconds(config.InitialBackoff),
+				MaxBackoff:           seconds(config.MaxBackoff),
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			},
+		}},
+		RetryThrottling: map[string]float64{"maxTokens": 10, "tokenRatio": 0.1},
+	}
+
+	data, err := json.Marshal(sc)
+	if err != nil {
+		panic(fmt.Sprintf("resilience: cannot encode service config: %v", err))
+	}
+	return string(data)
+}
+
+// seconds formats d the way service configs expect durations, as in "0.1s".
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
+
+// UnaryClientInterceptor applies the deadline from config and the circuit
+// breaker for the named service to every call. It runs before gRPC's own
+// retries, so the deadline covers all attempts and the breaker sees one
+// outcome per call.
+func UnaryClientInterceptor(name string, config Config) grpc.UnaryClientInterceptor {
+	breaker := BreakerFor(name, config.Breaker)
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := breaker.Allow(); err != nil {
+			return err
+		}
+
+		timeout, ok := config.Timeouts[method]
+		if !ok {
+			timeout = config.Timeout
+		}
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		breaker.Done(err)
+		return err
+	}
+}
+
+// DialOptions returns the options that apply config to a connection to the
+// named service.
+func DialOptions(name string, config Config) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(ServiceConfig(config)),
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(name, config)),
+	}
+}
@@ -0,0 +1,254 @@
+// Package servicetest holds the fixtures shared by the services' tests: a
+// migrated test database, signed tokens, an in-memory gRPC connection and the
+// checks every versioned resource's update and delete must pass over both
+// HTTP and gRPC.
+package servicetest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/etag"
+	"github.com/daffaromero/gobook/services/common/migrate"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Outcomes of a write, the same over HTTP and gRPC.
+const (
+	Written  = "written"
+	NotFound = "not found"
+	Stale    = "stale"
+)
+
+// OpenDB connects to the PostgreSQL database named by the environment
+// variable env and applies migrations to it. Tests using it are skipped
+// without one.
+func OpenDB(t *testing.T, env string, migrations fs.FS) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv(env)
+	if url == "" {
+		t.Skip(env + " is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrate.New(pool, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+// Token returns a token manager and a token it signed for a new user with
+// role.
+func Token(t *testing.T, role string) (*auth.TokenManager, string) {
+	t.Helper()
+
+	tokens, err := auth.NewTokenManager("test-secret-test-secret-test-secret", "gobook-test", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := tokens.Generate(uuid.New().String(), role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens, token
+}
+
+// Dial serves server in memory and returns a connection to it. Both are
+// closed when the test ends.
+func Dial(t *testing.T, server *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// WithToken returns a context that sends token on gRPC calls.
+func WithToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// Writer updates and deletes a resource at an expected version over one
+// transport and reports the outcome.
+type Writer struct {
+	Name   string
+	Update func(id string, version int64) string
+	Delete func(id string, version int64) string
+}
+
+// HTTPWriter writes the resources under prefix on app with PATCH and DELETE
+// requests. Updates send the body returned by update.
+func HTTPWriter(app *fiber.App, prefix, token string, update func() string) Writer {
+	write := func(method, id string, version int64, body string) string {
+		req := httptest.NewRequest(method, prefix+"/"+id, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(fiber.HeaderIfMatch, etag.Format(version))
+
+		res, err := app.Test(req, 10*time.Second)
+		if err != nil {
+			return fmt.Sprintf("HTTP request failed: %v", err)
+		}
+		defer res.Body.Close()
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			return Written
+		case http.StatusNotFound:
+			return NotFound
+		case http.StatusPreconditionFailed:
+			return Stale
+		}
+		return fmt.Sprintf("HTTP %d", res.StatusCode)
+	}
+
+	return Writer{
+		Name: "HTTP",
+		Update: func(id string, version int64) string {
+			return write(http.MethodPatch, id, version, update())
+		},
+		Delete: func(id string, version int64) string {
+			return write(http.MethodDelete, id, version, "")
+		},
+	}
+}
+
+// GRPCOutcome reports the outcome of a gRPC write that returned err.
+func GRPCOutcome(err error) string {
+	switch status.Code(err) {
+	case codes.OK:
+		return Written
+	case codes.NotFound:
+		return NotFound
+	case codes.FailedPrecondition:
+		return Stale
+	}
+	return fmt.Sprintf("gRPC %s: %v", status.Code(err), err)
+}
+
+// TestWrites checks that each writer reports missing and deleted resources as
+// not found and rejects writes at a stale version, and that only one of
+// several concurrent deletes succeeds. create adds a resource and returns its
+// id and version.
+func TestWrites(t *testing.T, writers []Writer, create func(t *testing.T) (string, int64)) {
+	for _, w := range writers {
+		t.Run(w.Name, func(t *testing.T) {
+			t.Run("missing", func(t *testing.T) {
+				id := uuid.New().String()
+				if got := w.Update(id, 1); got != NotFound {
+					t.Errorf("update = %s, want %s", got, NotFound)
+				}
+				if got := w.Delete(id, 1); got != NotFound {
+					t.Errorf("delete = %s, want %s", got, NotFound)
+				}
+			})
+
+			t.Run("deleted", func(t *testing.T) {
+				id, version := create(t)
+				if got := w.Delete(id, version); got != Written {
+					t.Fatalf("first delete = %s, want %s", got, Written)
+				}
+
+				// The delete bumped the version, so neither the old nor the
+				// new one finds a live resource.
+				for _, v := range []int64{version, version + 1} {
+					if got := w.Update(id, v); got != NotFound {
+						t.Errorf("update at version %d = %s, want %s", v, got, NotFound)
+					}
+					if got := w.Delete(id, v); got != NotFound {
+						t.Errorf("delete at version %d = %s, want %s", v, got, NotFound)
+					}
+				}
+			})
+
+			t.Run("stale version", func(t *testing.T) {
+				id, version := create(t)
+				if got := w.Update(id, version); got != Written {
+					t.Fatalf("first update = %s, want %s", got, Written)
+				}
+
+				if got := w.Update(id, version); got != Stale {
+					t.Errorf("update = %s, want %s", got, Stale)
+				}
+				if got := w.Delete(id, version); got != Stale {
+					t.Errorf("delete = %s, want %s", got, Stale)
+				}
+			})
+
+			t.Run("concurrent delete", func(t *testing.T) {
+				id, version := create(t)
+
+				const callers = 4
+				outcomes := make([]string, callers)
+				var wg sync.WaitGroup
+				for i := range outcomes {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						outcomes[i] = w.Delete(id, version)
+					}()
+				}
+				wg.Wait()
+
+				deleted := 0
+				for _, got := range outcomes {
+					switch got {
+					case Written:
+						deleted++
+					case NotFound:
+					default:
+						t.Errorf("concurrent delete = %s, want %s or %s", got, Written, NotFound)
+					}
+				}
+				if deleted != 1 {
+					t.Errorf("%d concurrent deletes succeeded, want 1 (outcomes: %v)", deleted, outcomes)
+				}
+
+				if got := w.Update(id, version); got != NotFound {
+					t.Errorf("update after concurrent delete = %s, want %s", got, NotFound)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TimeQuery parses an optional RFC 3339 query parameter.
+func TimeQuery(ctx fiber.Ctx, name string) (*timestamppb.Timestamp, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return timestamppb.New(t), nil
+}
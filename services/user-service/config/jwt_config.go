@@ -0,0 +1,23 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/utils"
+)
+
+var (
+	JWTSecret         = utils.GetEnv("JWT_SECRET")
+	JWTPrivateKeyPath = utils.GetEnv("JWT_PRIVATE_KEY_PATH")
+	JWTIssuer         = utils.GetEnv("JWT_ISSUER")
+	JWTExpiry         = jwtExpiry()
+)
+
+func jwtExpiry() time.Duration {
+	hours, err := strconv.Atoi(utils.GetEnv("JWT_EXPIRY_HOURS"))
+	if err != nil || hours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(hours) * time.Hour
+}
@@ -0,0 +1,27 @@
+package config
+
+import "github.com/daffaromero/gobook/services/common/auth"
+
+var (
+	adminOnly = []string{auth.RoleAdmin}
+	staff     = []string{auth.RoleAdmin, auth.RoleLibrarian}
+	signedIn  = []string{auth.RoleAdmin, auth.RoleLibrarian, auth.RoleReader}
+)
+
+// AccessRules lists the roles allowed to perform each action. RPC method names
+// apply to gRPC calls; ManageUsers and UpdateUserRole guard the HTTP routes
+// where users may otherwise act on their own account. GetUser is open to any
+// signed-in user for their own account, over HTTP and gRPC alike; reading
+// anyone else's takes ViewUsers, which librarians need to look up borrowers.
+// GenerateJWT signs a token for any user without checking a credential, so
+// only admins may call it; everyone else signs in with AuthUser.
+var AccessRules = map[string][]string{
+	"GetUser":        signedIn,
+	"ViewUsers":      staff,
+	"ListUsers":      adminOnly,
+	"UpdateUser":     adminOnly,
+	"DeleteUser":     adminOnly,
+	"ManageUsers":    adminOnly,
+	"UpdateUserRole": adminOnly,
+	"GenerateJWT":    adminOnly,
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/user-service/config"
+	"github.com/daffaromero/gobook/services/user-service/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+)
+
+type UserController interface {
+	Route(*fiber.App)
+	GetUser(ctx fiber.Ctx) error
+	ListUsers(ctx fiber.Ctx) error
+	CreateUser(ctx fiber.Ctx) error
+	UpdateUser(ctx fiber.Ctx) error
+	DeleteUser(ctx fiber.Ctx) error
+	AuthUser(ctx fiber.Ctx) error
+}
+
+type userController struct {
+	validate     *validator.Validate
+	service      service.UserService
+	authenticate fiber.Handler
+	policy       *auth.Policy
+}
+
+func NewUserController(validate *validator.Validate, service service.UserService, authenticate fiber.Handler, policy *auth.Policy) UserController {
+	return &userController{
+		validate:     validate,
+		service:      service,
+		authenticate: authenticate,
+		policy:       policy,
+	}
+}
+
+func (c *userController) Route(app *fiber.App) {
+	api := app.Group(config.EndpointPrefix)
+	api.Post("/auth", c.AuthUser)
+	api.Post("/new", c.CreateUser)
+	api.Get("/:id", c.GetUser, c.authenticate)
+	api.Get("/", c.ListUsers, c.authenticate, c.policy.Require("ListUsers"))
+	api.Put("/:id", c.UpdateUser, c.authenticate)
+	api.Delete("/:id", c.DeleteUser, c.authenticate)
+}
+
+func (c *userController) GetUser(ctx fiber.Ctx) error {
+	var req api.GetUserRequest
+	req.UserId = ctx.Params("id")
+	if req.UserId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id not provided"})
+	}
+
+	if err := c.authorizeSelf(ctx, req.UserId, "ViewUsers"); err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	res, err := c.service.GetUser(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *userController) ListUsers(ctx fiber.Ctx) error {
+	var req api.ListUsersRequest
+
+	res, err := c.service.ListUsers(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *userController) CreateUser(ctx fiber.Ctx) error {
+	var req api.CreateUserRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.User == nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user cannot be empty"})
+	}
+
+	if req.User.Username == "" || req.User.Password == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "username and password are required"})
+	}
+
+	if err := c.validate.Var(req.User.Email, "required,email"); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "a valid email is required"})
+	}
+
+	if err := c.validate.Var(req.User.Password, "min=8,max=72"); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "password must be between 8 and 72 characters"})
+	}
+
+	res, err := c.service.CreateUser(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (c *userController) UpdateUser(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id not provided"})
+	}
+
+	if err := c.authorizeSelf(ctx, id, "ManageUsers"); err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	var req api.UpdateUserRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.User == nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user cannot be empty"})
+	}
+	req.User.Id = id
+
+	if req.User.Username == "" && req.User.Email == "" && req.User.Password == "" && req.User.Role == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "no fields to update"})
+	}
+
+	if req.User.Role != "" {
+		if err := c.policy.Authorize(ctx.Context(), "UpdateUserRole"); err != nil {
+			return errs.Respond(ctx, err)
+		}
+	}
+
+	if req.User.Email != "" {
+		if err := c.validate.Var(req.User.Email, "email"); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "a valid email is required"})
+		}
+	}
+
+	if req.User.Password != "" {
+		if err := c.validate.Var(req.User.Password, "min=8,max=72"); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "password must be between 8 and 72 characters"})
+		}
+	}
+
+	res, err := c.service.UpdateUser(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *userController) DeleteUser(ctx fiber.Ctx) error {
+	var req api.DeleteUserRequest
+	req.UserId = ctx.Params("id")
+	if req.UserId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id not provided"})
+	}
+
+	if err := c.authorizeSelf(ctx, req.UserId, "ManageUsers"); err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	res, err := c.service.DeleteUser(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *userController) AuthUser(ctx fiber.Ctx) error {
+	var req api.AuthUserRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.Username == "" || req.Password == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "username and password are required"})
+	}
+
+	res, err := c.service.AuthUser(ctx.Context(), &req)
+	if err != nil {
+		return errs.Respond(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// authorizeSelf lets users act on their own account and requires the rule
+// for action on anyone else's.
+func (c *userController) authorizeSelf(ctx fiber.Ctx, id, action string) error {
+	if auth.UserID(ctx) == id {
+		return nil
+	}
+	return c.policy.Authorize(ctx.Context(), action)
+}
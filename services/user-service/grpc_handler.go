@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/user-service/service"
+	"google.golang.org/grpc"
+)
+
+type UserGRPCHandler struct {
+	api.UnimplementedUserServiceServer
+
+	service service.UserService
+	policy  *auth.Policy
+}
+
+func NewUserGRPCHandler(server *grpc.Server, service service.UserService, policy *auth.Policy) {
+	handler := &UserGRPCHandler{
+		service: service,
+		policy:  policy,
+	}
+
+	api.RegisterUserServiceServer(server, handler)
+}
+
+// GetUser returns the caller's own account, or anyone's to callers allowed to
+// ViewUsers, as the HTTP route does.
+func (h *UserGRPCHandler) GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error) {
+	if claims, ok := auth.FromContext(ctx); !ok || claims.Subject != req.GetUserId() {
+		if err := h.policy.Authorize(ctx, "ViewUsers"); err != nil {
+			return nil, err
+		}
+	}
+	return h.service.GetUser(ctx, req)
+}
+
+func (h *UserGRPCHandler) ListUsers(ctx context.Context, req *api.ListUsersRequest) (*api.ListUsersResponse, error) {
+	return h.service.ListUsers(ctx, req)
+}
+
+func (h *UserGRPCHandler) CreateUser(ctx context.Context, req *api.CreateUserRequest) (*api.CreateUserResponse, error) {
+	return h.service.CreateUser(ctx, req)
+}
+
+func (h *UserGRPCHandler) UpdateUser(ctx context.Context, req *api.UpdateUserRequest) (*api.UpdateUserResponse, error) {
+	return h.service.UpdateUser(ctx, req)
+}
+
+func (h *UserGRPCHandler) DeleteUser(ctx context.Context, req *api.DeleteUserRequest) (*api.DeleteUserResponse, error) {
+	return h.service.DeleteUser(ctx, req)
+}
+
+func (h *UserGRPCHandler) AuthUser(ctx context.Context, req *api.AuthUserRequest) (*api.AuthUserResponse, error) {
+	return h.service.AuthUser(ctx, req)
+}
+
+func (h *UserGRPCHandler) GenerateJWT(ctx context.Context, req *api.GenerateJWTRequest) (*api.GenerateJWTResponse, error) {
+	return h.service.GenerateJWT(ctx, req)
+}
+
+func (h *UserGRPCHandler) ValidateJWT(ctx context.Context, req *api.ValidateJWTRequest) (*api.ValidateJWTResponse, error) {
+	return h.service.ValidateJWT(ctx, req)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/servicetest"
+	"github.com/daffaromero/gobook/services/user-service/config"
+	"github.com/daffaromero/gobook/services/user-service/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenService answers GenerateJWT and GetUser and records whether it was
+// reached.
+type tokenService struct {
+	service.UserService
+
+	called bool
+}
+
+func (s *tokenService) GenerateJWT(ctx context.Context, req *api.GenerateJWTRequest) (*api.GenerateJWTResponse, error) {
+	s.called = true
+	return &api.GenerateJWTResponse{Token: "token-for-" + req.UserId}, nil
+}
+
+func (s *tokenService) GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error) {
+	s.called = true
+	return &api.GetUserResponse{User: &api.User{Id: req.UserId}}, nil
+}
+
+func TestGenerateJWTRequiresAdmin(t *testing.T) {
+	tokens, err := auth.NewTokenManager("test-secret-test-secret-test-secret", "gobook-test", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		role string
+		want codes.Code
+	}{
+		{name: "anonymous", want: codes.Unauthenticated},
+		{name: "reader", role: auth.RoleReader, want: codes.PermissionDenied},
+		{name: "librarian", role: auth.RoleLibrarian, want: codes.PermissionDenied},
+		{name: "admin", role: auth.RoleAdmin, want: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &tokenService{}
+			client := newTestClient(t, tokens, svc)
+
+			ctx := context.Background()
+			if tt.role != "" {
+				token, err := tokens.Generate("caller", tt.role)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+			}
+
+			_, err := client.GenerateJWT(ctx, &api.GenerateJWTRequest{UserId: "admin-id"})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("GenerateJWT() code = %s, want %s (err: %v)", got, tt.want, err)
+			}
+			if svc.called != (tt.want == codes.OK) {
+				t.Fatalf("service reached = %t, want %t", svc.called, tt.want == codes.OK)
+			}
+		})
+	}
+}
+
+func TestGetUserRequiresSelfOrStaff(t *testing.T) {
+	tokens, err := auth.NewTokenManager("test-secret-test-secret-test-secret", "gobook-test", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		role   string
+		caller string
+		want   codes.Code
+	}{
+		{name: "anonymous", want: codes.Unauthenticated},
+		{name: "reader reading themself", role: auth.RoleReader, caller: "member-id", want: codes.OK},
+		{name: "reader reading someone else", role: auth.RoleReader, caller: "other-id", want: codes.PermissionDenied},
+		{name: "librarian", role: auth.RoleLibrarian, caller: "librarian-id", want: codes.OK},
+		{name: "admin", role: auth.RoleAdmin, caller: "admin-id", want: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &tokenService{}
+			client := newTestClient(t, tokens, svc)
+
+			ctx := context.Background()
+			if tt.role != "" {
+				token, err := tokens.Generate(tt.caller, tt.role)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+			}
+
+			_, err := client.GetUser(ctx, &api.GetUserRequest{UserId: "member-id"})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("GetUser() code = %s, want %s (err: %v)", got, tt.want, err)
+			}
+			if svc.called != (tt.want == codes.OK) {
+				t.Fatalf("service reached = %t, want %t", svc.called, tt.want == codes.OK)
+			}
+		})
+	}
+}
+
+func newTestClient(t *testing.T, tokens *auth.TokenManager, svc service.UserService) api.UserServiceClient {
+	t.Helper()
+
+	policy := auth.NewPolicy(config.AccessRules)
+	server := newGRPCServer(tokens, policy)
+	NewUserGRPCHandler(server, svc, policy)
+
+	return api.NewUserServiceClient(servicetest.Dial(t, server))
+}
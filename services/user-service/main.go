@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/discovery"
+	"github.com/daffaromero/gobook/services/common/discovery/consul"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/daffaromero/gobook/services/common/migrate"
+	"github.com/daffaromero/gobook/services/user-service/config"
+	"github.com/daffaromero/gobook/services/user-service/controller"
+	"github.com/daffaromero/gobook/services/user-service/migrations"
+	"github.com/daffaromero/gobook/services/user-service/repository"
+	"github.com/daffaromero/gobook/services/user-service/repository/query"
+	"github.com/daffaromero/gobook/services/user-service/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+var logs = logger.New("main")
+
+func webServer() error {
+	app := fiber.New()
+	app.Use(requestid.New())
+
+	serverConfig := config.NewServerConfig()
+	dbConfig := config.NewPostgresDatabase()
+	store := repository.NewStore(dbConfig)
+
+	if config.AutoMigrate {
+		migrator, err := migrate.New(dbConfig, migrations.FS)
+		if err != nil {
+			logs.Error(fmt.Sprintf("Failed to load migrations: %v", err))
+			return err
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			logs.Error(fmt.Sprintf("Failed to apply migrations: %v", err))
+			return err
+		}
+	}
+	validate := validator.New()
+
+	tokens, err := newTokenManager()
+	if err != nil {
+		logs.Error("Failed to create token manager for user service")
+		return err
+	}
+
+	registry, err := consul.NewRegistry(serverConfig.ConsulAddr, serverConfig.Name)
+	if err != nil {
+		logs.Error("Failed to create consul registry for user service")
+		return err
+	}
+
+	GRPCserviceID := discovery.GenerateServiceID(serverConfig.Name + "-grpc")
+	HTTPserviceID := discovery.GenerateServiceID(serverConfig.Name + "-http")
+
+	grpcPortInt, _ := strconv.Atoi(serverConfig.GRPCPort)
+	httpPortInt, _ := strconv.Atoi(serverConfig.HTTPPort)
+
+	ctx := context.Background()
+
+	err = registry.RegisterService(ctx, serverConfig.Name+"-grpc", GRPCserviceID, serverConfig.GRPCAddr, grpcPortInt, []string{"grpc"})
+	if err != nil {
+		logs.Error("Failed to register gRPC user service to consul")
+		return err
+	}
+
+	err = registry.RegisterService(ctx, serverConfig.Name, HTTPserviceID, serverConfig.HTTPAddr, httpPortInt, []string{"http"})
+	if err != nil {
+		logs.Error("Failed to register HTTP user service to consul")
+		return err
+	}
+
+	go func() {
+		failureCount := 0
+		const maxFailures = 5
+		for {
+			err := registry.HealthCheck(GRPCserviceID, serverConfig.Name+"-grpc")
+			if err != nil {
+				logs.Error(fmt.Sprintf("Failed to perform health check for gRPC service: %v", err))
+				failureCount++
+				if failureCount >= maxFailures {
+					logs.Error("Max health check failures reached for gRPC service. Exiting health check loop.")
+					break
+				}
+			} else {
+				failureCount = 0
+			}
+			time.Sleep(time.Second * 2)
+		}
+	}()
+	defer registry.DeregisterService(ctx, GRPCserviceID)
+
+	go func() {
+		failureCount := 0
+		const maxFailures = 5
+		for {
+			err := registry.HealthCheck(HTTPserviceID, serverConfig.Name)
+			if err != nil {
+				logs.Error(fmt.Sprintf("Failed to perform health check: %v", err))
+				failureCount++
+				if failureCount >= maxFailures {
+					logs.Error("Max health check failures reached for HTTP service. Exiting health check loop.")
+					break
+				}
+			} else {
+				failureCount = 0
+			}
+			time.Sleep(time.Second * 2)
+		}
+	}()
+	defer registry.DeregisterService(ctx, HTTPserviceID)
+
+	userQuery := query.NewUserQuery(dbConfig)
+	userRepo := repository.NewUserRepository(store, userQuery)
+	userService := service.NewUserService(userRepo, tokens, logs)
+	policy := auth.NewPolicy(config.AccessRules)
+	userController := controller.NewUserController(validate, userService, auth.Middleware(tokens), policy)
+
+	go func() {
+		// gRPC server + reflection
+		grpcServer := newGRPCServer(tokens, policy)
+		reflection.Register(grpcServer)
+
+		l, err := net.Listen("tcp", serverConfig.GRPC)
+		if err != nil {
+			// The service cannot do without its gRPC API, so stop it here.
+			logs.Error(fmt.Sprintf("Failed to listen: %v", err))
+			os.Exit(1)
+		}
+		logs.Log(fmt.Sprintf("gRPC server started on %s", serverConfig.GRPC))
+		defer l.Close()
+
+		NewUserGRPCHandler(grpcServer, userService, policy)
+
+		if err := grpcServer.Serve(l); err != nil {
+			logs.Error(fmt.Sprintf("Failed to start gRPC user server: %v", err))
+		}
+	}()
+
+	// HTTP server (Fiber)
+	logs.Log(fmt.Sprintf("Starting HTTP user server on %s", serverConfig.HTTP))
+	app.Use(cors.New())
+	userController.Route(app)
+
+	err = app.Listen(serverConfig.HTTP, fiber.ListenConfig{
+		DisableStartupMessage: true,
+	})
+	if err != nil {
+		logs.Error(fmt.Sprintf("Failed to start HTTP user server: %v", err))
+		return err
+	}
+
+	return nil
+}
+
+func newTokenManager() (*auth.TokenManager, error) {
+	if config.JWTPrivateKeyPath != "" {
+		key, err := os.ReadFile(config.JWTPrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwt private key: %w", err)
+		}
+		return auth.NewRSATokenManager(key, config.JWTIssuer, config.JWTExpiry)
+	}
+	return auth.NewTokenManager(config.JWTSecret, config.JWTIssuer, config.JWTExpiry)
+}
+
+// newGRPCServer returns a gRPC server that authenticates callers with tokens
+// and enforces policy on every call.
+func newGRPCServer(tokens auth.Validator, policy *auth.Policy) *grpc.Server {
+	return grpc.NewServer(grpc.ChainUnaryInterceptor(
+		errs.UnaryServerInterceptor(),
+		auth.UnaryServerInterceptor(tokens),
+		policy.UnaryServerInterceptor(),
+	))
+}
+
+// migrateCommand handles "migrate up|down [steps]|status" without starting
+// the servers.
+func migrateCommand(args []string) error {
+	migrator, err := migrate.New(config.NewPostgresDatabase(), migrations.FS)
+	if err != nil {
+		return err
+	}
+	return migrator.Run(context.Background(), args, os.Stdout)
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrateCommand(os.Args[2:]); err != nil {
+			logs.Error(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := webServer(); err != nil {
+		logs.Error(err)
+	}
+
+	logs.Log("User server started")
+
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
+
+	sig := <-sigchan
+	logs.Log(fmt.Sprintf("Received signal: %s. Shutting down gracefully...", sig))
+}
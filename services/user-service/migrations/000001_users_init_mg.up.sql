@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS users (
+  "id" uuid PRIMARY KEY,
+  "username" VARCHAR(255) NOT NULL UNIQUE,
+  "email" VARCHAR(255) NOT NULL UNIQUE,
+  "password" VARCHAR(255) NOT NULL,
+  "role" VARCHAR(32) NOT NULL DEFAULT 'reader',
+  "created_at" TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  "deleted_at" TIMESTAMPTZ DEFAULT NULL
+);
\ No newline at end of file
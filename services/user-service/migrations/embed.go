@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS holds the versioned up/down migrations applied by common/migrate.
+//
+//go:embed *.sql
+var FS embed.FS
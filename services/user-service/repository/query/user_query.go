@@ -0,0 +1,187 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type UserQuery interface {
+	GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error)
+	GetUserByUsername(ctx context.Context, username string) (*api.User, error)
+	ListUsers(ctx context.Context, req *api.ListUsersRequest) (*api.ListUsersResponse, error)
+	CreateUser(ctx context.Context, tx pgx.Tx, req *api.CreateUserRequest) (*api.CreateUserResponse, error)
+	UpdateUser(ctx context.Context, tx pgx.Tx, req *api.UpdateUserRequest) (*api.UpdateUserResponse, error)
+	DeleteUser(ctx context.Context, tx pgx.Tx, req *api.DeleteUserRequest) (*api.DeleteUserResponse, error)
+}
+
+type userQuery struct {
+	db *pgxpool.Pool
+}
+
+func NewUserQuery(db *pgxpool.Pool) *userQuery {
+	return &userQuery{
+		db: db,
+	}
+}
+
+func (q *userQuery) GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error) {
+	if req == nil || req.UserId == "" {
+		return nil, errs.New(errs.InvalidArgument, "user ID cannot be empty")
+	}
+	query := `SELECT id, username, email, role, created_at, updated_at FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+	var user api.User
+	var createdAt, updatedAt time.Time
+	err := q.db.QueryRow(ctx, query, req.UserId).Scan(&user.Id, &user.Username, &user.Email, &user.Role, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "user with ID %s not found", req.UserId)
+		}
+		return nil, fmt.Errorf("failed to scan user: %w", errs.DB(err))
+	}
+	user.CreatedAt = timestamppb.New(createdAt)
+	user.UpdatedAt = timestamppb.New(updatedAt)
+
+	return &api.GetUserResponse{
+		User: &user,
+	}, nil
+}
+
+// GetUserByUsername is the only lookup that returns the password hash, for credential checks.
+func (q *userQuery) GetUserByUsername(ctx context.Context, username string) (*api.User, error) {
+	if username == "" {
+		return nil, errs.New(errs.InvalidArgument, "username cannot be empty")
+	}
+	query := `SELECT id, username, email, password, role FROM users WHERE username = $1 AND deleted_at IS NULL`
+
+	var user api.User
+	err := q.db.QueryRow(ctx, query, username).Scan(&user.Id, &user.Username, &user.Email, &user.Password, &user.Role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "user %s not found", username)
+		}
+		return nil, fmt.Errorf("failed to scan user: %w", errs.DB(err))
+	}
+
+	return &user, nil
+}
+
+func (q *userQuery) ListUsers(ctx context.Context, req *api.ListUsersRequest) (*api.ListUsersResponse, error) {
+	query := `SELECT id, username, email, role, created_at, updated_at FROM users WHERE deleted_at IS NULL`
+
+	rows, err := q.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*api.User
+	for rows.Next() {
+		var user api.User
+		var createdAt, updatedAt time.Time
+		err := rows.Scan(&user.Id, &user.Username, &user.Email, &user.Role, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", errs.DB(err))
+		}
+		user.CreatedAt = timestamppb.New(createdAt)
+		user.UpdatedAt = timestamppb.New(updatedAt)
+		users = append(users, &user)
+	}
+
+	return &api.ListUsersResponse{
+		Users: users,
+	}, nil
+}
+
+func (q *userQuery) CreateUser(ctx context.Context, tx pgx.Tx, req *api.CreateUserRequest) (*api.CreateUserResponse, error) {
+	if req == nil || req.User == nil {
+		return nil, errs.New(errs.InvalidArgument, "user cannot be empty")
+	}
+	query := `INSERT INTO users (id, username, email, password, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, username, email, role`
+
+	createdAt := req.User.CreatedAt.AsTime()
+	updatedAt := req.User.UpdatedAt.AsTime()
+
+	var createdUser api.User
+
+	err := tx.QueryRow(ctx, query, req.User.Id, req.User.Username, req.User.Email, req.User.Password, req.User.Role, createdAt, updatedAt).Scan(&createdUser.Id, &createdUser.Username, &createdUser.Email, &createdUser.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert user: %w", errs.DB(err))
+	}
+	createdUser.CreatedAt = req.User.CreatedAt
+	createdUser.UpdatedAt = req.User.UpdatedAt
+
+	return &api.CreateUserResponse{
+		User: &createdUser,
+	}, nil
+}
+
+func (q *userQuery) UpdateUser(ctx context.Context, tx pgx.Tx, req *api.UpdateUserRequest) (*api.UpdateUserResponse, error) {
+	if req == nil || req.User == nil {
+		return nil, errs.New(errs.InvalidArgument, "user cannot be empty")
+	}
+	if req.User.Id == "" {
+		return nil, errs.New(errs.InvalidArgument, "user ID cannot be empty")
+	}
+
+	// Empty strings mean "leave unchanged".
+	query := `UPDATE users
+		SET
+			username = COALESCE(NULLIF($2, ''), username),
+			email = COALESCE(NULLIF($3, ''), email),
+			password = COALESCE(NULLIF($4, ''), password),
+			role = COALESCE(NULLIF($5, ''), role),
+			updated_at = $6
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, username, email, role, created_at, updated_at`
+
+	updatedAt := time.Now()
+	if req.User.UpdatedAt != nil {
+		updatedAt = req.User.UpdatedAt.AsTime()
+	}
+
+	var updatedUser api.User
+	var createdAt time.Time
+
+	err := tx.QueryRow(ctx, query, req.User.Id, req.User.Username, req.User.Email, req.User.Password, req.User.Role, updatedAt).Scan(&updatedUser.Id, &updatedUser.Username, &updatedUser.Email, &updatedUser.Role, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.Newf(errs.NotFound, "user with ID %s not found", req.User.Id)
+		}
+		return nil, fmt.Errorf("failed to update user: %w", errs.DB(err))
+	}
+	updatedUser.CreatedAt = timestamppb.New(createdAt)
+	updatedUser.UpdatedAt = timestamppb.New(updatedAt)
+
+	return &api.UpdateUserResponse{
+		User: &updatedUser,
+	}, nil
+}
+
+func (q *userQuery) DeleteUser(ctx context.Context, tx pgx.Tx, req *api.DeleteUserRequest) (*api.DeleteUserResponse, error) {
+	if req == nil || req.UserId == "" {
+		return nil, errs.New(errs.InvalidArgument, "user ID cannot be empty")
+	}
+
+	query := `UPDATE users SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	tag, err := tx.Exec(ctx, query, req.UserId, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, errs.Newf(errs.NotFound, "user with ID %s not found", req.UserId)
+	}
+
+	return &api.DeleteUserResponse{
+		Success: true,
+	}, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/user-service/repository/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UserRepository interface {
+	GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error)
+	GetUserByUsername(ctx context.Context, username string) (*api.User, error)
+	ListUsers(ctx context.Context, req *api.ListUsersRequest) (*api.ListUsersResponse, error)
+	CreateUser(ctx context.Context, req *api.CreateUserRequest) (*api.CreateUserResponse, error)
+	UpdateUser(ctx context.Context, req *api.UpdateUserRequest) (*api.UpdateUserResponse, error)
+	DeleteUser(ctx context.Context, req *api.DeleteUserRequest) (*api.DeleteUserResponse, error)
+}
+
+type userRepository struct {
+	db        Store
+	userQuery query.UserQuery
+}
+
+func NewUserRepository(db Store, userQuery query.UserQuery) UserRepository {
+	return &userRepository{
+		db:        db,
+		userQuery: userQuery,
+	}
+}
+
+func (r *userRepository) GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error) {
+	var user *api.GetUserResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		user, err = r.userQuery.GetUser(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*api.User, error) {
+	var user *api.User
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		user, err = r.userQuery.GetUserByUsername(ctx, username)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) ListUsers(ctx context.Context, req *api.ListUsersRequest) (*api.ListUsersResponse, error) {
+	var users *api.ListUsersResponse
+
+	err := r.db.WithoutTx(ctx, func(pool *pgxpool.Pool) error {
+		var err error
+		users, err = r.userQuery.ListUsers(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, req *api.CreateUserRequest) (*api.CreateUserResponse, error) {
+	var user *api.CreateUserResponse
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		user, err = r.userQuery.CreateUser(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) UpdateUser(ctx context.Context, req *api.UpdateUserRequest) (*api.UpdateUserResponse, error) {
+	var user *api.UpdateUserResponse
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		user, err = r.userQuery.UpdateUser(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, req *api.DeleteUserRequest) (*api.DeleteUserResponse, error) {
+	var res *api.DeleteUserResponse
+
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.userQuery.DeleteUser(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+	return res, nil
+}
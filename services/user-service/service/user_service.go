@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/daffaromero/gobook/services/user-service/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type UserService interface {
+	GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error)
+	ListUsers(ctx context.Context, req *api.ListUsersRequest) (*api.ListUsersResponse, error)
+	CreateUser(ctx context.Context, req *api.CreateUserRequest) (*api.CreateUserResponse, error)
+	UpdateUser(ctx context.Context, req *api.UpdateUserRequest) (*api.UpdateUserResponse, error)
+	DeleteUser(ctx context.Context, req *api.DeleteUserRequest) (*api.DeleteUserResponse, error)
+	AuthUser(ctx context.Context, req *api.AuthUserRequest) (*api.AuthUserResponse, error)
+	GenerateJWT(ctx context.Context, req *api.GenerateJWTRequest) (*api.GenerateJWTResponse, error)
+	ValidateJWT(ctx context.Context, req *api.ValidateJWTRequest) (*api.ValidateJWTResponse, error)
+}
+
+type userService struct {
+	repo   repository.UserRepository
+	tokens *auth.TokenManager
+	logger *logger.Log
+}
+
+func NewUserService(repo repository.UserRepository, tokens *auth.TokenManager, logger *logger.Log) UserService {
+	return &userService{
+		repo:   repo,
+		tokens: tokens,
+		logger: logger,
+	}
+}
+
+func (s *userService) GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error) {
+	user, err := s.repo.GetUser(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to get user: %v", err))
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *userService) ListUsers(ctx context.Context, req *api.ListUsersRequest) (*api.ListUsersResponse, error) {
+	users, err := s.repo.ListUsers(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to list users: %v", err))
+		return nil, err
+	}
+	return users, nil
+}
+
+func (s *userService) CreateUser(ctx context.Context, req *api.CreateUserRequest) (*api.CreateUserResponse, error) {
+	if req.User == nil {
+		return nil, errs.New(errs.InvalidArgument, "user cannot be empty")
+	}
+	if req.User.Username == "" || req.User.Email == "" || req.User.Password == "" {
+		return nil, errs.New(errs.InvalidArgument, "username, email and password are required")
+	}
+
+	hash, err := hashPassword(req.User.Password)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to hash password: %v", err))
+		return nil, err
+	}
+
+	now := timestamppb.New(time.Now())
+	req.User.Id = uuid.New().String()
+	req.User.Password = hash
+	req.User.Role = auth.RoleReader
+	req.User.CreatedAt = now
+	req.User.UpdatedAt = now
+
+	res, err := s.repo.CreateUser(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to create user: %v", err))
+		if errs.Is(err, errs.AlreadyExists) {
+			return nil, errs.New(errs.AlreadyExists, "Username or email already taken.")
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, req *api.UpdateUserRequest) (*api.UpdateUserResponse, error) {
+	if req.User == nil || req.User.Id == "" {
+		return nil, errs.New(errs.InvalidArgument, "user cannot be empty")
+	}
+	if req.User.Role != "" && !auth.ValidRole(req.User.Role) {
+		return nil, errs.Newf(errs.InvalidArgument, "unknown role %q", req.User.Role)
+	}
+	if req.User.Password != "" {
+		hash, err := hashPassword(req.User.Password)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to hash password: %v", err))
+			return nil, err
+		}
+		req.User.Password = hash
+	}
+	req.User.UpdatedAt = timestamppb.New(time.Now())
+
+	res, err := s.repo.UpdateUser(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to update user: %v", err))
+		if errs.Is(err, errs.AlreadyExists) {
+			return nil, errs.New(errs.AlreadyExists, "Username or email already taken.")
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, req *api.DeleteUserRequest) (*api.DeleteUserResponse, error) {
+	res, err := s.repo.DeleteUser(ctx, req)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to delete user: %v", err))
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *userService) AuthUser(ctx context.Context, req *api.AuthUserRequest) (*api.AuthUserResponse, error) {
+	if req.Username == "" || req.Password == "" {
+		return nil, errs.New(errs.InvalidArgument, "username and password are required")
+	}
+
+	user, err := s.repo.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to authenticate user: %v", err))
+		return nil, errs.New(errs.Unauthenticated, "Invalid username or password.")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return nil, errs.New(errs.Unauthenticated, "Invalid username or password.")
+	}
+
+	token, err := s.tokens.Generate(user.Id, user.Role)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to generate token: %v", err))
+		return nil, err
+	}
+
+	return &api.AuthUserResponse{
+		Token: token,
+	}, nil
+}
+
+func (s *userService) GenerateJWT(ctx context.Context, req *api.GenerateJWTRequest) (*api.GenerateJWTResponse, error) {
+	user, err := s.repo.GetUser(ctx, &api.GetUserRequest{UserId: req.UserId})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to get user for token: %v", err))
+		return nil, err
+	}
+
+	token, err := s.tokens.Generate(user.User.Id, user.User.Role)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to generate token: %v", err))
+		return nil, err
+	}
+
+	return &api.GenerateJWTResponse{
+		Token: token,
+	}, nil
+}
+
+func (s *userService) ValidateJWT(ctx context.Context, req *api.ValidateJWTRequest) (*api.ValidateJWTResponse, error) {
+	claims, err := s.tokens.Validate(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return &api.ValidateJWTResponse{Valid: false}, nil
+		}
+		return nil, err
+	}
+
+	// A token outlives the account it was issued for and the role it was
+	// issued with; reject tokens of deleted users and answer with the role the
+	// user has now.
+	user, err := s.repo.GetUser(ctx, &api.GetUserRequest{UserId: claims.Subject})
+	if err != nil {
+		if errs.Is(err, errs.NotFound) {
+			return &api.ValidateJWTResponse{Valid: false}, nil
+		}
+		s.logger.Error(fmt.Sprintf("Failed to get user for token: %v", err))
+		return nil, errs.Wrap(errs.Unavailable, err, "token owner could not be looked up")
+	}
+
+	return &api.ValidateJWTResponse{
+		Valid:  true,
+		UserId: user.User.Id,
+		Role:   user.User.Role,
+	}, nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	api "github.com/daffaromero/gobook/protobuf/api"
+	"github.com/daffaromero/gobook/services/common/auth"
+	"github.com/daffaromero/gobook/services/common/errs"
+	"github.com/daffaromero/gobook/services/common/helper/logger"
+	"github.com/daffaromero/gobook/services/user-service/repository"
+)
+
+// userLookup answers GetUser with user, or with err when it is set.
+type userLookup struct {
+	repository.UserRepository
+
+	user *api.User
+	err  error
+}
+
+func (r *userLookup) GetUser(ctx context.Context, req *api.GetUserRequest) (*api.GetUserResponse, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &api.GetUserResponse{User: r.user}, nil
+}
+
+func TestValidateJWT(t *testing.T) {
+	tokens, err := auth.NewTokenManager("test-secret-test-secret-test-secret", "gobook-test", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The token was issued while the user was an admin.
+	token, err := tokens.Generate("user-id", auth.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("demoted", func(t *testing.T) {
+		svc := NewUserService(&userLookup{user: &api.User{Id: "user-id", Role: auth.RoleReader}}, tokens, logger.New("test"))
+
+		res, err := svc.ValidateJWT(context.Background(), &api.ValidateJWTRequest{Token: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Valid || res.Role != auth.RoleReader {
+			t.Errorf("ValidateJWT = valid %t, role %q, want valid with role %q", res.Valid, res.Role, auth.RoleReader)
+		}
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		svc := NewUserService(&userLookup{err: errs.New(errs.NotFound, "user not found")}, tokens, logger.New("test"))
+
+		res, err := svc.ValidateJWT(context.Background(), &api.ValidateJWTRequest{Token: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Valid {
+			t.Error("ValidateJWT accepted the token of a deleted user")
+		}
+	})
+
+	t.Run("lookup failed", func(t *testing.T) {
+		svc := NewUserService(&userLookup{err: errors.New("connection refused")}, tokens, logger.New("test"))
+
+		_, err := svc.ValidateJWT(context.Background(), &api.ValidateJWTRequest{Token: token})
+		if !errs.Is(err, errs.Unavailable) {
+			t.Errorf("ValidateJWT error = %v, want %s", err, errs.Unavailable)
+		}
+	})
+}